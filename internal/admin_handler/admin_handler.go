@@ -0,0 +1,508 @@
+package adminhandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/auth"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliocache "github.com/NH-Homelab/portfolio-backend/internal/portfolio_cache"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/preview"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+// Longest lifetime a preview link can be requested with
+const maxPreviewTtl = 30 * 24 * time.Hour
+
+type AdminHandler struct {
+	dao        *portfoliocache.CachedDao
+	previews   *preview.Signer
+	previewTtl time.Duration
+}
+
+type createProjectRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Status      models.Project_Status `json:"status"` // defaults to draft
+
+	// Optional initial milestones, created in the same transaction as the project
+	Milestones []models.Milestone `json:"milestones"`
+}
+
+// A project without its milestones, exactly what its ETag covers. Milestones
+// carry versions of their own, writing one leaves the project's tag unchanged.
+type projectResponse struct {
+	ID          int                   `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Status      models.Project_Status `json:"status"`
+	Created_at  time.Time             `json:"created_at"`
+	Version     int                   `json:"version"`
+}
+
+type createApiKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type createPreviewRequest struct {
+	Ttl string `json:"ttl"` // Go duration, e.g. "48h", defaults to PREVIEW_TTL
+}
+
+type previewResponse struct {
+	Token      string    `json:"token"`
+	Expires_at time.Time `json:"expires_at"`
+	Url        string    `json:"url"`
+}
+
+type createdResponse struct {
+	ID int `json:"id"`
+}
+
+type createdApiKeyResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+func NewAdminHandler(dao *portfoliocache.CachedDao, previews *preview.Signer, previewTtl time.Duration) *AdminHandler {
+	return &AdminHandler{dao, previews, previewTtl}
+}
+
+// requireAuth rejects requests without a principal attached by auth.Middleware.
+// The middleware already rejects unauthenticated writes, this also covers admin reads.
+func requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+			problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (ah *AdminHandler) RegisterHandlers(mux *http.ServeMux) {
+	// retrieves all projects, including unpublished ones
+	mux.HandleFunc("GET /api/admin/projects", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		projects, err := ah.dao.GetAllProjects(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve projects: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(projects); err != nil {
+			log.Printf("Failed to encode projects response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// retrieves a project, its milestones are listed separately
+	mux.HandleFunc("GET /api/admin/projects/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+
+		project, err := ah.dao.GetProjectById(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve project %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(project.Version))
+		if err := json.NewEncoder(w).Encode(projectResponse{
+			ID:          project.ID,
+			Name:        project.Name,
+			Description: project.Description,
+			Status:      project.Status,
+			Created_at:  project.Created_at,
+			Version:     project.Version,
+		}); err != nil {
+			log.Printf("Failed to encode project response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// retrieves all milestones of a project, whatever their status
+	mux.HandleFunc("GET /api/admin/projects/{id}/milestones", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+
+		project, err := ah.dao.GetProjectById(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve project %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(project.Milestones); err != nil {
+			log.Printf("Failed to encode milestones response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// creates a project along with any initial milestones
+	mux.HandleFunc("POST /api/admin/projects", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		var req createProjectRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Status == "" {
+			req.Status = models.Project_Draft
+		}
+
+		var id int
+		err := ah.dao.WithTx(r.Context(), func(tx *portfoliodao.PortfolioDao) error {
+			var err error
+			id, err = tx.CreateProject(r.Context(), req.Name, req.Description, req.Status)
+			if err != nil {
+				return err
+			}
+
+			for i, m := range req.Milestones {
+				m.Project_id = id
+				if _, err := tx.CreateMilestone(r.Context(), m); err != nil {
+					var verrs models.Validation_Errors
+					if errors.As(err, &verrs) {
+						return verrs.Prefixed(fmt.Sprintf("milestones[%d]", i))
+					}
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to create project: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		writeCreated(w, id)
+	}))
+
+	// partially updates a project, fields absent from the body are left untouched
+	mux.HandleFunc("PATCH /api/admin/projects/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		var update portfoliodao.ProjectUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := ah.dao.UpdateProject(r.Context(), id, version, update); err != nil {
+			log.Printf("Failed to update project %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// moves a project and its milestones to the trash
+	mux.HandleFunc("DELETE /api/admin/projects/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		if err := ah.dao.DeleteProject(r.Context(), id, version); err != nil {
+			log.Printf("Failed to delete project %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// creates a milestone
+	mux.HandleFunc("POST /api/admin/milestones", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		var m models.Milestone
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		id, err := ah.dao.CreateMilestone(r.Context(), m)
+		if err != nil {
+			log.Printf("Failed to create milestone: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		writeCreated(w, id)
+	}))
+
+	// retrieves a milestone whatever its status
+	mux.HandleFunc("GET /api/admin/milestones/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+
+		milestone, err := ah.dao.GetMilestoneById(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(milestone.Version))
+		if err := json.NewEncoder(w).Encode(milestone); err != nil {
+			log.Printf("Failed to encode milestone response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// partially updates a milestone, fields absent from the body are left untouched
+	mux.HandleFunc("PATCH /api/admin/milestones/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		var update portfoliodao.MilestoneUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := ah.dao.UpdateMilestone(r.Context(), id, version, update); err != nil {
+			log.Printf("Failed to update milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// retrieves the status history of a milestone
+	mux.HandleFunc("GET /api/admin/milestones/{id}/transitions", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+
+		transitions, err := ah.dao.GetMilestoneStatusTransitions(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve status transitions of milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(transitions); err != nil {
+			log.Printf("Failed to encode transitions response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// mints a preview link for a project, valid even while it is unpublished
+	mux.HandleFunc("POST /api/admin/projects/{id}/preview", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+
+		if _, err := ah.dao.GetProjectById(r.Context(), id); err != nil {
+			log.Printf("Failed to retrieve project %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		ah.writePreview(w, r, preview.Project, id, "/api/projects/")
+	}))
+
+	// mints a preview link for a milestone, valid even while it is unpublished
+	mux.HandleFunc("POST /api/admin/milestones/{id}/preview", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+
+		if _, err := ah.dao.GetMilestoneById(r.Context(), id); err != nil {
+			log.Printf("Failed to retrieve milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		ah.writePreview(w, r, preview.Milestone, id, "/api/milestones/")
+	}))
+
+	// moves a milestone to the trash
+	mux.HandleFunc("DELETE /api/admin/milestones/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+
+		if err := ah.dao.DeleteMilestone(r.Context(), id, version); err != nil {
+			log.Printf("Failed to delete milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// retrieves the hit and miss counters of the read cache
+	mux.HandleFunc("GET /api/admin/cache", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(ah.dao.Stats()); err != nil {
+			log.Printf("Failed to encode cache stats response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// lists api keys, never including the key material
+	mux.HandleFunc("GET /api/admin/api-keys", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		keys, err := ah.dao.GetAllApiKeys(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve api keys: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(keys); err != nil {
+			log.Printf("Failed to encode api keys response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// creates an api key, the plaintext key is only ever returned here
+	mux.HandleFunc("POST /api/admin/api-keys", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		var req createApiKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		key, hash, err := auth.GenerateApiKey()
+		if err != nil {
+			log.Printf("Failed to generate api key: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to create api key")
+			return
+		}
+
+		id, err := ah.dao.CreateApiKey(r.Context(), req.Name, hash)
+		if err != nil {
+			log.Printf("Failed to create api key: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(createdApiKeyResponse{ID: id, Name: req.Name, Key: key}); err != nil {
+			log.Printf("Failed to encode api key response: %v", err)
+		}
+	}))
+
+	// revokes an api key
+	mux.HandleFunc("DELETE /api/admin/api-keys/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid api key ID")
+			return
+		}
+
+		if err := ah.dao.DeleteApiKey(r.Context(), id); err != nil {
+			log.Printf("Failed to delete api key %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	ah.registerTrashHandlers(mux)
+	ah.registerRevisionHandlers(mux)
+	ah.registerWebhookHandlers(mux)
+}
+
+// writePreview mints a preview token for kind and id and responds with it and
+// the public URL it unlocks. The request body is optional.
+func (ah *AdminHandler) writePreview(w http.ResponseWriter, r *http.Request, kind string, id int, path string) {
+	var req createPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ttl := ah.previewTtl
+	if req.Ttl != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.Ttl)
+		if err != nil || ttl <= 0 || ttl > maxPreviewTtl {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid ttl, must be a positive duration of at most "+maxPreviewTtl.String())
+			return
+		}
+	}
+
+	token, expires, err := ah.previews.Mint(kind, id, ttl)
+	if err != nil {
+		log.Printf("Failed to mint preview of %s %d: %v", kind, id, err)
+		if errors.Is(err, preview.ErrDisabled) {
+			problem.Write(w, r, http.StatusServiceUnavailable, "Previews are not configured")
+		} else {
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to create preview")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	resp := previewResponse{
+		Token:      token,
+		Expires_at: expires,
+		Url:        path + strconv.Itoa(id) + "?preview=" + url.QueryEscape(token),
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to encode preview response: %v", err)
+	}
+}
+
+// writeCreated responds with 201 and the id of the created row
+func writeCreated(w http.ResponseWriter, id int) {
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createdResponse{ID: id}); err != nil {
+		log.Printf("Failed to encode created response: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package adminhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/auth"
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliocache "github.com/NH-Homelab/portfolio-backend/internal/portfolio_cache"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/preview"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+// newTestMux returns the admin routes backed by a migrated in-memory database
+func newTestMux(t *testing.T) (*http.ServeMux, *portfoliocache.CachedDao) {
+	t.Helper()
+
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dao := portfoliocache.NewCachedDao(portfoliodao.NewPortfolioDao(db, 5*time.Second), time.Minute, 100)
+	mux := http.NewServeMux()
+	NewAdminHandler(dao, preview.NewSigner([]byte("secret")), time.Hour).RegisterHandlers(mux)
+	return mux, dao
+}
+
+// get performs an authenticated GET against mux
+func get(mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: "test", Method: auth.MethodApiKey}))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestProjectETagCoversItsBody(t *testing.T) {
+	mux, dao := newTestMux(t)
+	ctx := context.Background()
+
+	id, err := dao.CreateProject(ctx, "project", "", models.Project_Draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := get(mux, "/api/admin/projects/1")
+
+	milestone := models.Milestone{Title: "milestone", Milestone_date: time.Now().UTC(), Milestone_type: models.Minor, Project_id: id, Tags: []string{"go"}}
+	milestoneID, err := dao.CreateMilestone(ctx, milestone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := "renamed"
+	if err := dao.UpdateMilestone(ctx, milestoneID, 0, portfoliodao.MilestoneUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	after := get(mux, "/api/admin/projects/1")
+
+	if before.Code != http.StatusOK || after.Code != http.StatusOK {
+		t.Fatalf("status = %d, %d, want 200", before.Code, after.Code)
+	}
+	// A tag that stays the same must label the same body
+	if before.Header().Get("ETag") != after.Header().Get("ETag") {
+		t.Fatalf("ETag changed from %s to %s after milestone writes", before.Header().Get("ETag"), after.Header().Get("ETag"))
+	}
+	if before.Body.String() != after.Body.String() {
+		t.Errorf("body under ETag %s changed from %s to %s", after.Header().Get("ETag"), before.Body.String(), after.Body.String())
+	}
+
+	milestones := get(mux, "/api/admin/projects/1/milestones")
+	if milestones.Code != http.StatusOK {
+		t.Fatalf("milestones status = %d, want 200", milestones.Code)
+	}
+	if body := milestones.Body.String(); !strings.Contains(body, `"title":"renamed"`) {
+		t.Errorf("milestones = %s, want the renamed milestone", body)
+	}
+}
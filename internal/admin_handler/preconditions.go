@@ -0,0 +1,40 @@
+package adminhandler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+// etag formats a row version as a strong entity tag
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the version a write is conditional on from If-Match.
+// A missing header or "*" yields 0, which makes the write unconditional. On
+// failure the error response has been written and ok is false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if strings.Contains(header, ",") {
+		problem.Write(w, r, http.StatusBadRequest, "If-Match must hold a single entity tag")
+		return 0, false
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires,
+	// and neither do tags this server did not issue
+	unquoted, found := strings.CutPrefix(header, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+	version, err := strconv.Atoi(unquoted)
+	if !found || !closed || err != nil || version <= 0 {
+		problem.Write(w, r, http.StatusPreconditionFailed, "If-Match does not match the current entity tag")
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,54 @@
+package adminhandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		ok      bool
+		status  int // written on failure
+	}{
+		{name: "missing", header: "", version: 0, ok: true},
+		{name: "any", header: "*", version: 0, ok: true},
+		{name: "strong tag", header: `"7"`, version: 7, ok: true},
+		{name: "surrounding whitespace", header: ` "7" `, version: 7, ok: true},
+		{name: "etag round trip", header: etag(42), version: 42, ok: true},
+		{name: "list of tags", header: `"1", "2"`, ok: false, status: http.StatusBadRequest},
+		{name: "weak tag", header: `W/"7"`, ok: false, status: http.StatusPreconditionFailed},
+		{name: "unquoted", header: `7`, ok: false, status: http.StatusPreconditionFailed},
+		{name: "missing closing quote", header: `"7`, ok: false, status: http.StatusPreconditionFailed},
+		{name: "not a version", header: `"abc"`, ok: false, status: http.StatusPreconditionFailed},
+		{name: "zero", header: `"0"`, ok: false, status: http.StatusPreconditionFailed},
+		{name: "negative", header: `"-3"`, ok: false, status: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/projects/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			version, ok := ifMatchVersion(w, r)
+			if ok != tt.ok || version != tt.version {
+				t.Fatalf("ifMatchVersion(%q) = %d, %v, want %d, %v", tt.header, version, ok, tt.version, tt.ok)
+			}
+			if ok {
+				if w.Body.Len() != 0 {
+					t.Errorf("wrote a response on success: %s", w.Body.String())
+				}
+				return
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
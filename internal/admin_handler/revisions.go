@@ -0,0 +1,102 @@
+package adminhandler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+func (ah *AdminHandler) registerRevisionHandlers(mux *http.ServeMux) {
+	// lists the revisions of a project, oldest first
+	mux.HandleFunc("GET /api/admin/projects/{id}/revisions", requireAuth(ah.listRevisions(portfoliodao.EntityProject)))
+
+	// lists the revisions of a milestone, oldest first
+	mux.HandleFunc("GET /api/admin/milestones/{id}/revisions", requireAuth(ah.listRevisions(portfoliodao.EntityMilestone)))
+
+	// compares a project after two of its revisions, ?from= and ?to= are revision ids
+	mux.HandleFunc("GET /api/admin/projects/{id}/revisions/diff", requireAuth(ah.diffRevisions(portfoliodao.EntityProject)))
+
+	// compares a milestone after two of its revisions, ?from= and ?to= are revision ids
+	mux.HandleFunc("GET /api/admin/milestones/{id}/revisions/diff", requireAuth(ah.diffRevisions(portfoliodao.EntityMilestone)))
+
+	// reverts a project to its state after the revision
+	mux.HandleFunc("POST /api/admin/projects/{id}/revisions/{revision}/revert", requireAuth(revert(ah.dao.RevertProject)))
+
+	// reverts a milestone to its state after the revision
+	mux.HandleFunc("POST /api/admin/milestones/{id}/revisions/{revision}/revert", requireAuth(revert(ah.dao.RevertMilestone)))
+}
+
+func (ah *AdminHandler) listRevisions(entity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid "+entity+" ID")
+			return
+		}
+
+		revisions, err := ah.dao.GetRevisions(r.Context(), entity, id)
+		if err != nil {
+			log.Printf("Failed to retrieve revisions of %s %d: %v", entity, id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(revisions); err != nil {
+			log.Printf("Failed to encode revisions response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}
+}
+
+func (ah *AdminHandler) diffRevisions(entity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid "+entity+" ID")
+			return
+		}
+
+		from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+		to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+		if fromErr != nil || toErr != nil {
+			problem.Write(w, r, http.StatusBadRequest, "from and to must be revision IDs")
+			return
+		}
+
+		changes, err := ah.dao.DiffRevisions(r.Context(), entity, id, from, to)
+		if err != nil {
+			log.Printf("Failed to diff revisions %d and %d of %s %d: %v", from, to, entity, id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			log.Printf("Failed to encode diff response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}
+}
+
+func revert(action func(ctx context.Context, id, revision int) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, idErr := strconv.Atoi(r.PathValue("id"))
+		revision, revErr := strconv.Atoi(r.PathValue("revision"))
+		if idErr != nil || revErr != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid ID")
+			return
+		}
+
+		if err := action(r.Context(), id, revision); err != nil {
+			log.Printf("Failed to revert %s to revision %d: %v", r.URL.Path, revision, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,59 @@
+package adminhandler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+func (ah *AdminHandler) registerTrashHandlers(mux *http.ServeMux) {
+	// lists the trashed projects and milestones, most recently deleted first
+	mux.HandleFunc("GET /api/admin/trash", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		items, err := ah.dao.GetTrash(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve trash: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(items); err != nil {
+			log.Printf("Failed to encode trash response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// restores a trashed project along with the milestones deleted with it
+	mux.HandleFunc("POST /api/admin/trash/projects/{id}/restore", requireAuth(trashAction("restore project", ah.dao.RestoreProject)))
+
+	// restores a trashed milestone, its project must not be in the trash
+	mux.HandleFunc("POST /api/admin/trash/milestones/{id}/restore", requireAuth(trashAction("restore milestone", ah.dao.RestoreMilestone)))
+
+	// permanently deletes a trashed project and its milestones
+	mux.HandleFunc("DELETE /api/admin/trash/projects/{id}", requireAuth(trashAction("purge project", ah.dao.PurgeProject)))
+
+	// permanently deletes a trashed milestone
+	mux.HandleFunc("DELETE /api/admin/trash/milestones/{id}", requireAuth(trashAction("purge milestone", ah.dao.PurgeMilestone)))
+}
+
+// trashAction runs action on the id in the path and responds 204 on success
+func trashAction(op string, action func(ctx context.Context, id int) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid ID")
+			return
+		}
+
+		if err := action(r.Context(), id); err != nil {
+			log.Printf("Failed to %s %d: %v", op, id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
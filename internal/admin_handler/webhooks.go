@@ -0,0 +1,122 @@
+package adminhandler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+	"github.com/NH-Homelab/portfolio-backend/internal/webhooks"
+)
+
+type createWebhookRequest struct {
+	Url    string   `json:"url"`
+	Events []string `json:"events"` // defaults to milestone.published
+	Secret string   `json:"secret"` // generated when empty
+}
+
+type createdWebhookResponse struct {
+	ID     int    `json:"id"`
+	Secret string `json:"secret"`
+}
+
+func (ah *AdminHandler) registerWebhookHandlers(mux *http.ServeMux) {
+	// lists webhooks, never including their secrets
+	mux.HandleFunc("GET /api/admin/webhooks", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		hooks, err := ah.dao.GetAllWebhooks(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve webhooks: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(hooks); err != nil {
+			log.Printf("Failed to encode webhooks response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// creates a webhook, the signing secret is only ever returned here
+	mux.HandleFunc("POST /api/admin/webhooks", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		var req createWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.Events == nil {
+			req.Events = []string{"milestone.published"}
+		}
+		if req.Secret == "" {
+			secret, err := webhooks.GenerateSecret()
+			if err != nil {
+				log.Printf("Failed to generate webhook secret: %v", err)
+				problem.Write(w, r, http.StatusInternalServerError, "Failed to create webhook")
+				return
+			}
+			req.Secret = secret
+		}
+
+		id, err := ah.dao.CreateWebhook(r.Context(), models.Webhook{Url: req.Url, Secret: req.Secret, Events: req.Events})
+		if err != nil {
+			log.Printf("Failed to create webhook: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(createdWebhookResponse{ID: id, Secret: req.Secret}); err != nil {
+			log.Printf("Failed to encode webhook response: %v", err)
+		}
+	}))
+
+	// deletes a webhook along with its pending and failed deliveries
+	mux.HandleFunc("DELETE /api/admin/webhooks/{id}", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid webhook ID")
+			return
+		}
+
+		if err := ah.dao.DeleteWebhook(r.Context(), id); err != nil {
+			log.Printf("Failed to delete webhook %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// lists the deliveries that failed every attempt, most recent first
+	mux.HandleFunc("GET /api/admin/webhooks/dead-letters", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		deliveries, err := ah.dao.GetWebhookDeadLetters(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve webhook dead letters: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+			log.Printf("Failed to encode webhook dead letters response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	}))
+
+	// queues a failed delivery again, it is sent in the background
+	mux.HandleFunc("POST /api/admin/webhooks/dead-letters/{id}/replay", requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid dead letter ID")
+			return
+		}
+
+		if err := ah.dao.ReplayWebhookDeadLetter(r.Context(), id); err != nil {
+			log.Printf("Failed to replay webhook dead letter %d: %v", id, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+const (
+	MethodApiKey = "api_key"
+	MethodJwt    = "jwt"
+
+	apiKeyHeader = "X-API-Key"
+	apiKeyPrefix = "pk_"
+
+	// How stale an API key's last use may get before it is recorded again,
+	// so reads do not each cost a write
+	touchInterval = time.Minute
+)
+
+// Returned for credentials that were supplied but are not valid. Any other
+// authentication error is a failure to check them.
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// Identity of an authenticated caller
+type Principal struct {
+	Subject string `json:"subject"`
+	Method  string `json:"method"`
+}
+
+type principalKey struct{}
+
+type Authenticator struct {
+	dao        *portfoliodao.PortfolioDao
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+}
+
+// Create new instance of Authenticator. JWTs are only accepted for the
+// signing methods whose key is configured (non-empty secret / non-nil key).
+func NewAuthenticator(dao *portfoliodao.PortfolioDao, hmacSecret []byte, rsaKey *rsa.PublicKey) *Authenticator {
+	return &Authenticator{dao, hmacSecret, rsaKey}
+}
+
+// Middleware attaches the caller's Principal to the request context and
+// rejects unauthenticated requests to anything other than read-only methods
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.authenticate(r)
+		if errors.Is(err, errInvalidCredentials) {
+			log.Printf("Authentication failed for %s %s: %v", r.Method, r.URL.Path, err)
+			problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if err != nil {
+			// Valid credentials must not look rejected while they cannot be checked
+			log.Printf("Failed to authenticate %s %s: %v", r.Method, r.URL.Path, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		if principal == nil {
+			if !isReadOnly(r.Method) {
+				problem.Write(w, r, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := WithPrincipal(r.Context(), principal)
+		ctx = portfoliodao.WithActor(ctx, principal.Subject)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate returns the principal for the supplied credentials, nil if no
+// credentials were supplied, or an error wrapping errInvalidCredentials if the
+// credentials are invalid
+func (a *Authenticator) authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return a.authenticateApiKey(r.Context(), key)
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.authenticateJwt(token)
+	}
+
+	return nil, nil
+}
+
+func (a *Authenticator) authenticateApiKey(ctx context.Context, key string) (*Principal, error) {
+	apiKey, err := a.dao.GetApiKeyByHash(ctx, HashApiKey(key))
+	if errors.Is(err, portfoliodao.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown api key", errInvalidCredentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey.Last_used_at == nil || time.Since(*apiKey.Last_used_at) >= touchInterval {
+		if err := a.dao.TouchApiKey(ctx, apiKey.ID); err != nil {
+			log.Printf("Failed to record use of api key %d: %v", apiKey.ID, err)
+		}
+	}
+
+	return &Principal{Subject: apiKey.Name, Method: MethodApiKey}, nil
+}
+
+func (a *Authenticator) authenticateJwt(tokenStr string) (*Principal, error) {
+	var methods []string
+	if len(a.hmacSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.rsaKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%w: jwt authentication is not configured", errInvalidCredentials)
+	}
+
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return a.hmacSecret, nil
+		case *jwt.SigningMethodRSA:
+			return a.rsaKey, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}, jwt.WithValidMethods(methods), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid jwt: %v", errInvalidCredentials, err)
+	}
+
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: jwt is missing a subject", errInvalidCredentials)
+	}
+
+	return &Principal{Subject: subject, Method: MethodJwt}, nil
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal attached by Middleware, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// GenerateApiKey returns a new random API key and the hash to store for it
+func GenerateApiKey() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, HashApiKey(key), nil
+}
+
+// HashApiKey returns the hex encoded SHA-256 of an API key. Keys are high
+// entropy random values so a fast hash is sufficient.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadRSAPublicKey reads a PEM encoded RSA public key from disk
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rsa public key: %w", err)
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rsa public key: %w", err)
+	}
+
+	return key, nil
+}
+
+func isReadOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+// newTestDao returns a DAO backed by a migrated in-memory database
+func newTestDao(t *testing.T) (*portfoliodao.PortfolioDao, *sqlitedb.SqliteDB) {
+	t.Helper()
+
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return portfoliodao.NewPortfolioDao(db, 5*time.Second), db
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	dao, db := newTestDao(t)
+	key, hash, err := GenerateApiKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.CreateApiKey(context.Background(), "test", hash); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		closeDB bool
+		status  int
+	}{
+		{name: "valid api key", header: apiKeyHeader, value: key, status: http.StatusNoContent},
+		{name: "unknown api key", header: apiKeyHeader, value: "pk_unknown", status: http.StatusUnauthorized},
+		{name: "jwt without configured keys", header: "Authorization", value: "Bearer abc", status: http.StatusUnauthorized},
+		{name: "database unavailable", header: apiKeyHeader, value: key, closeDB: true, status: http.StatusInternalServerError},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := NewAuthenticator(dao, nil, nil).Middleware(next)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.closeDB {
+				db.Close()
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/projects", nil)
+			r.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestApiKeyUseIsThrottled(t *testing.T) {
+	dao, db := newTestDao(t)
+	ctx := context.Background()
+	key, hash, err := GenerateApiKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := dao.CreateApiKey(ctx, "test", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAuthenticator(dao, nil, nil)
+	lastUsed := func() *time.Time {
+		t.Helper()
+		k, err := dao.GetApiKeyByHash(ctx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k.Last_used_at
+	}
+
+	if _, err := a.authenticateApiKey(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if lastUsed() == nil {
+		t.Fatal("first use was not recorded")
+	}
+
+	// A recent use is not recorded again
+	recent := time.Now().UTC().Add(-touchInterval / 2)
+	if _, err := db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2", recent, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.authenticateApiKey(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastUsed(); !got.Equal(recent) {
+		t.Errorf("last_used_at = %v, want %v kept", got, recent)
+	}
+
+	// A stale one is
+	stale := time.Now().UTC().Add(-2 * touchInterval)
+	if _, err := db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2", stale, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.authenticateApiKey(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastUsed(); !got.After(stale) {
+		t.Errorf("last_used_at = %v, want it updated from %v", got, stale)
+	}
+}
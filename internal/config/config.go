@@ -3,16 +3,57 @@ package config
 import (
 	"github.com/joho/godotenv"
 
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type BackendConfig struct {
+	Db_driver   string // postgres or sqlite
+	Db_path     string // sqlite only, file path or :memory:
 	Db_host     string
 	Db_port     string
 	Db_user     string
 	Db_password string
 	Db_name     string
+
+	// Upper bound on every database call, zero disables it
+	Db_query_timeout time.Duration
+
+	// How often scheduled milestones are checked for publishing and the trash is purged
+	Publish_interval time.Duration
+
+	// How long deleted projects and milestones stay in the trash, zero keeps them forever
+	Trash_retention time.Duration
+
+	// JWT verification keys, JWTs are rejected when neither is set
+	Jwt_hs256_secret     string
+	Jwt_rs256_public_key string // path to a PEM encoded public key
+
+	// Signs preview links for unpublished content, previews are disabled when empty
+	Preview_secret string
+	Preview_ttl    time.Duration // lifetime of a preview link unless the request sets one
+
+	// Bounds of the in-process read cache, a zero ttl disables it
+	Cache_ttl         time.Duration
+	Cache_max_entries int
+
+	// Number of recent events kept for clients resuming the event stream
+	Event_log_size int
+
+	// How often pending webhook deliveries are checked for being due
+	Webhook_poll_interval time.Duration
+
+	// Cache-Control of cacheable public responses, the header is omitted when empty
+	Public_cache_control string
+
+	// Title of the RSS and Atom feeds
+	Feed_title string
+	// Public url the feeds link to, the feeds are not served when empty
+	Feed_base_url string
 }
 
 func Load() (*BackendConfig, error) {
@@ -22,12 +63,78 @@ func Load() (*BackendConfig, error) {
 		log.Println("WARNING: No .env file found, using environment variables")
 	}
 
+	queryTimeout, err := time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_QUERY_TIMEOUT: %w", err)
+	}
+
+	publishInterval, err := time.ParseDuration(getEnv("PUBLISH_INTERVAL", "1m"))
+	if err != nil || publishInterval <= 0 {
+		return nil, fmt.Errorf("invalid PUBLISH_INTERVAL %q", getEnv("PUBLISH_INTERVAL", "1m"))
+	}
+
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil || trashRetention < 0 {
+		return nil, fmt.Errorf("invalid TRASH_RETENTION %q", getEnv("TRASH_RETENTION", "720h"))
+	}
+
+	previewTtl, err := time.ParseDuration(getEnv("PREVIEW_TTL", "24h"))
+	if err != nil || previewTtl <= 0 {
+		return nil, fmt.Errorf("invalid PREVIEW_TTL %q", getEnv("PREVIEW_TTL", "24h"))
+	}
+
+	cacheTtl, err := time.ParseDuration(getEnv("CACHE_TTL", "30s"))
+	if err != nil || cacheTtl < 0 {
+		return nil, fmt.Errorf("invalid CACHE_TTL %q", getEnv("CACHE_TTL", "30s"))
+	}
+
+	cacheMaxEntries, err := strconv.Atoi(getEnv("CACHE_MAX_ENTRIES", "1000"))
+	if err != nil || cacheMaxEntries < 0 {
+		return nil, fmt.Errorf("invalid CACHE_MAX_ENTRIES %q", getEnv("CACHE_MAX_ENTRIES", "1000"))
+	}
+
+	eventLogSize, err := strconv.Atoi(getEnv("EVENT_LOG_SIZE", "1000"))
+	if err != nil || eventLogSize < 0 {
+		return nil, fmt.Errorf("invalid EVENT_LOG_SIZE %q", getEnv("EVENT_LOG_SIZE", "1000"))
+	}
+
+	webhookPollInterval, err := time.ParseDuration(getEnv("WEBHOOK_POLL_INTERVAL", "10s"))
+	if err != nil || webhookPollInterval <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_POLL_INTERVAL %q", getEnv("WEBHOOK_POLL_INTERVAL", "10s"))
+	}
+
 	return &BackendConfig{
+		Db_driver:   getEnv("DB_DRIVER", "postgres"),
+		Db_path:     getEnv("DB_PATH", "portfolio.db"),
 		Db_host:     getEnv("DB_HOST", "localhost"),
 		Db_port:     getEnv("DB_PORT", "5432"),
 		Db_user:     getEnv("DB_USER", "postgres"),
 		Db_password: getEnv("DB_PASSWORD", "password"),
 		Db_name:     getEnv("DB_NAME", "postgres"),
+
+		Db_query_timeout: queryTimeout,
+
+		Publish_interval: publishInterval,
+
+		Trash_retention: trashRetention,
+
+		Jwt_hs256_secret:     getEnv("JWT_HS256_SECRET", ""),
+		Jwt_rs256_public_key: getEnv("JWT_RS256_PUBLIC_KEY", ""),
+
+		Preview_secret: getEnv("PREVIEW_SECRET", ""),
+		Preview_ttl:    previewTtl,
+
+		Cache_ttl:         cacheTtl,
+		Cache_max_entries: cacheMaxEntries,
+
+		Event_log_size: eventLogSize,
+
+		Webhook_poll_interval: webhookPollInterval,
+
+		Public_cache_control: getEnv("PUBLIC_CACHE_CONTROL", "public, max-age=60"),
+
+		Feed_title:    getEnv("FEED_TITLE", "Portfolio"),
+		Feed_base_url: strings.TrimSuffix(getEnv("FEED_BASE_URL", ""), "/"),
 	}, nil
 }
 
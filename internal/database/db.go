@@ -1,8 +1,34 @@
 package database
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
+
+// SQL dialect spoken by a Database implementation
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	Sqlite   Dialect = "sqlite"
+)
+
+// Queries are written for Postgres ($N placeholders), implementations
+// translate them to their own dialect where needed
+type Queryer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	Dialect() Dialect
+}
 
 type Database interface {
-	Exec(string, ...interface{}) (sql.Result, error)
-	Query(string, ...interface{}) (*sql.Rows, error)
+	Queryer
+	BeginTx(context.Context) (Tx, error)
+}
+
+// A transaction, queries run through it are committed or rolled back together
+type Tx interface {
+	Queryer
+	Commit() error
+	Rollback() error
 }
@@ -0,0 +1,200 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+// What happened to a project or milestone, as seen by readers of the public API
+const (
+	Created   = "created"
+	Updated   = "updated"
+	Published = "published"
+	Deleted   = "deleted"
+)
+
+// Buffered events per subscriber, one that falls further behind is dropped
+const subscriberBuffer = 64
+
+// A change of public content
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"` // <entity>.<action>, e.g. milestone.published
+	Entity    string    `json:"entity"`
+	Entity_id int       `json:"entity_id"`
+	Action    string    `json:"action"`
+	Time      time.Time `json:"time"`
+}
+
+// Log keeps the most recent events so subscribers can resume after a
+// reconnect, and passes new events on to the current subscribers
+type Log struct {
+	mu          sync.Mutex
+	events      []Event // ring buffer of at most size events
+	start       int     // index of the oldest event
+	size        int
+	nextID      uint64
+	subscribers map[chan Event]struct{}
+}
+
+// Create a Log retaining size events. IDs continue from the start time, so
+// ids handed out by an earlier process are never mistaken for current ones.
+func NewLog(size int) *Log {
+	return &Log{
+		events:      make([]Event, 0, size),
+		size:        size,
+		nextID:      uint64(time.Now().UnixMilli()),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Record appends the event a DAO change amounts to, if readers can see it.
+// Meant to be registered with PortfolioDao.OnChange.
+func (l *Log) Record(change portfoliodao.Change) {
+	action, ok := PublicAction(change)
+	if !ok {
+		return
+	}
+
+	l.Append(Event{
+		Type:      change.Entity + "." + action,
+		Entity:    change.Entity,
+		Entity_id: change.ID,
+		Action:    action,
+		Time:      time.Now().UTC(),
+	})
+}
+
+// Append assigns the event the next id, stores it and sends it to every
+// subscriber. Never blocks, subscribers that cannot keep up are dropped.
+func (l *Log) Append(e Event) Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.ID = l.nextID
+	l.nextID++
+
+	if len(l.events) < l.size {
+		l.events = append(l.events, e)
+	} else if l.size > 0 {
+		l.events[l.start] = e
+		l.start = (l.start + 1) % l.size
+	}
+
+	for ch := range l.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return e
+}
+
+// since returns the retained events after id, oldest first. ok is false if
+// events after id may have been dropped already, or id was never handed out.
+func (l *Log) since(id uint64) ([]Event, bool) {
+	if id >= l.nextID {
+		return nil, false
+	}
+
+	events := make([]Event, 0)
+	for i := 0; i < len(l.events); i++ {
+		e := l.events[(l.start+i)%len(l.events)]
+		if e.ID > id {
+			events = append(events, e)
+		}
+	}
+
+	// The event right after id must still be retained
+	oldest := l.nextID
+	if len(l.events) > 0 {
+		oldest = l.events[l.start].ID
+	}
+	return events, id+1 >= oldest
+}
+
+// A subscriber's view of the log
+type Subscription struct {
+	// Retained events the subscriber missed, oldest first
+	Backlog []Event
+	// False if events the subscriber missed are no longer retained, it has to
+	// start over from the current state
+	Resumed bool
+	// Id of the latest event when subscribing, the backlog ends with it
+	Latest uint64
+	// Every event appended after subscribing. Closed when the subscriber falls
+	// behind or the subscription is closed.
+	Events <-chan Event
+
+	cancel func()
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.cancel()
+}
+
+// Subscribe returns a subscription to the events appended from now on
+func (l *Log) Subscribe() *Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.subscribe(nil, true)
+}
+
+// Resume returns a subscription whose backlog holds the retained events after id
+func (l *Log) Resume(id uint64) *Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	backlog, ok := l.since(id)
+	if !ok {
+		backlog = nil
+	}
+	return l.subscribe(backlog, ok)
+}
+
+// subscribe registers a new subscriber, l.mu must be held
+func (l *Log) subscribe(backlog []Event, resumed bool) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	l.subscribers[ch] = struct{}{}
+
+	return &Subscription{
+		Backlog: backlog,
+		Resumed: resumed,
+		Latest:  l.nextID - 1,
+		Events:  ch,
+		cancel: func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if _, subscribed := l.subscribers[ch]; subscribed {
+				delete(l.subscribers, ch)
+				close(ch)
+			}
+		},
+	}
+}
+
+// PublicAction maps a change to the action readers see, ok is false for
+// changes to content that was not public before nor after it
+func PublicAction(change portfoliodao.Change) (action string, ok bool) {
+	switch {
+	case !change.WasPublic && !change.IsPublic:
+		return "", false
+	case !change.WasPublic:
+		if change.Action == portfoliodao.ActionCreate || change.Action == portfoliodao.ActionRestore {
+			// New, or back from the trash
+			return Created, true
+		}
+		return Published, true
+	case !change.IsPublic:
+		// Unpublished content disappears just like deleted content
+		return Deleted, true
+	}
+	return Updated, true
+}
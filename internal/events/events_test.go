@@ -0,0 +1,181 @@
+package events
+
+import (
+	"slices"
+	"testing"
+)
+
+// newFilledLog returns a log retaining size events after appending count, and
+// the ids handed out in order
+func newFilledLog(size, count int) (*Log, []uint64) {
+	l := NewLog(size)
+	ids := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		ids = append(ids, l.Append(Event{Entity: "milestone", Entity_id: i}).ID)
+	}
+	return l, ids
+}
+
+func eventIDs(events []Event) []uint64 {
+	ids := make([]uint64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		count   int
+		lastID  func(ids []uint64) uint64
+		resumed bool
+		backlog func(ids []uint64) []uint64
+	}{
+		{
+			name:    "inside the retained window",
+			size:    5,
+			count:   4,
+			lastID:  func(ids []uint64) uint64 { return ids[1] },
+			resumed: true,
+			backlog: func(ids []uint64) []uint64 { return ids[2:] },
+		},
+		{
+			name:    "at the latest event",
+			size:    5,
+			count:   4,
+			lastID:  func(ids []uint64) uint64 { return ids[3] },
+			resumed: true,
+			backlog: func(ids []uint64) []uint64 { return nil },
+		},
+		{
+			name:    "right before the oldest retained event after wrapping",
+			size:    3,
+			count:   7,
+			lastID:  func(ids []uint64) uint64 { return ids[3] },
+			resumed: true,
+			backlog: func(ids []uint64) []uint64 { return ids[4:] },
+		},
+		{
+			name:    "after the next event was evicted",
+			size:    3,
+			count:   7,
+			lastID:  func(ids []uint64) uint64 { return ids[2] },
+			resumed: false,
+		},
+		{
+			name:    "id from a previous process",
+			size:    5,
+			count:   2,
+			lastID:  func(ids []uint64) uint64 { return ids[0] - 1000 },
+			resumed: false,
+		},
+		{
+			name:    "id never handed out",
+			size:    5,
+			count:   2,
+			lastID:  func(ids []uint64) uint64 { return ids[1] + 1 },
+			resumed: false,
+		},
+		{
+			name:    "nothing retained, at the latest event",
+			size:    0,
+			count:   3,
+			lastID:  func(ids []uint64) uint64 { return ids[2] },
+			resumed: true,
+			backlog: func(ids []uint64) []uint64 { return nil },
+		},
+		{
+			name:    "nothing retained, behind the latest event",
+			size:    0,
+			count:   3,
+			lastID:  func(ids []uint64) uint64 { return ids[1] },
+			resumed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ids := newFilledLog(tt.size, tt.count)
+			sub := l.Resume(tt.lastID(ids))
+			defer sub.Close()
+
+			if sub.Resumed != tt.resumed {
+				t.Fatalf("Resumed = %v, want %v", sub.Resumed, tt.resumed)
+			}
+			if sub.Latest != ids[len(ids)-1] {
+				t.Errorf("Latest = %d, want %d", sub.Latest, ids[len(ids)-1])
+			}
+
+			var want []uint64
+			if tt.backlog != nil {
+				want = tt.backlog(ids)
+			}
+			if got := eventIDs(sub.Backlog); !slices.Equal(got, want) {
+				t.Errorf("Backlog = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	l, _ := newFilledLog(5, 2)
+	sub := l.Subscribe()
+	defer sub.Close()
+
+	if !sub.Resumed || len(sub.Backlog) != 0 {
+		t.Fatalf("Subscribe() = resumed %v with %d backlog events, want a resumed empty backlog", sub.Resumed, len(sub.Backlog))
+	}
+
+	e := l.Append(Event{Entity: "project", Entity_id: 1})
+	if got := <-sub.Events; got.ID != e.ID {
+		t.Errorf("received event %d, want %d", got.ID, e.ID)
+	}
+	if e.ID != sub.Latest+1 {
+		t.Errorf("appended event %d, want %d following Latest", e.ID, sub.Latest+1)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	l := NewLog(0)
+	slow := l.Subscribe()
+	defer slow.Close()
+
+	// Fills the buffer, the event after that drops the subscriber
+	for i := 0; i <= subscriberBuffer; i++ {
+		l.Append(Event{Entity: "milestone", Entity_id: i})
+	}
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d events before the channel closed, want %d", received, subscriberBuffer)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.subscribers) != 0 {
+		t.Errorf("%d subscribers left, want 0", len(l.subscribers))
+	}
+}
+
+func TestCloseEndsSubscription(t *testing.T) {
+	l := NewLog(5)
+	sub := l.Subscribe()
+	sub.Close()
+	// Closing twice must not close the channel twice
+	sub.Close()
+
+	if _, open := <-sub.Events; open {
+		t.Error("Events still open after Close")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.subscribers) != 0 {
+		t.Errorf("%d subscribers left, want 0", len(l.subscribers))
+	}
+}
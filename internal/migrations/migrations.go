@@ -0,0 +1,237 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/database"
+)
+
+// Each dialect has its own copy of every migration under sql/<dialect>
+//
+//go:embed sql
+var migrationFiles embed.FS
+
+var createSchemaMigrations = map[database.Dialect]string{
+	database.Postgres: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	database.Sqlite: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+}
+
+const (
+	getAppliedMigrations = `
+		SELECT version, applied_at
+		FROM schema_migrations
+		ORDER BY version`
+	recordMigration = `
+		INSERT INTO schema_migrations (version) VALUES ($1)`
+	removeMigration = `
+		DELETE FROM schema_migrations WHERE version = $1`
+)
+
+// A numbered migration loaded from sql/<dialect>/NNNN_name.{up,down}.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// The applied state of a single migration
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied_at *time.Time
+}
+
+type Migrator struct {
+	db         database.Database
+	migrations []Migration
+}
+
+// Create new instance of Migrator with the embedded migrations for the database's dialect
+func NewMigrator(db database.Database) (*Migrator, error) {
+	if _, ok := createSchemaMigrations[db.Dialect()]; !ok {
+		return nil, fmt.Errorf("no migrations for dialect %q", db.Dialect())
+	}
+
+	migrations, err := load(migrationFiles, "sql/"+string(db.Dialect()))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db, migrations}, nil
+}
+
+// load parses the migration files in dir, sorted by version
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		versionStr, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+
+		contents, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d is missing its up or down script", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// applied returns the applied_at time of every applied version
+func (mg *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if _, err := mg.db.ExecContext(ctx, createSchemaMigrations[mg.db.Dialect()]); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := mg.db.QueryContext(ctx, getAppliedMigrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration row: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// run executes a migration script and the statement recording it in one transaction
+func (mg *Migrator) run(ctx context.Context, script, record string, version int) error {
+	tx, err := mg.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Up applies every pending migration in order and returns how many were applied
+func (mg *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range mg.migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := mg.run(ctx, m.Up, recordMigration, m.Version); err != nil {
+			return count, fmt.Errorf("failed to apply migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Down reverts up to steps of the most recently applied migrations and returns how many were reverted
+func (mg *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(mg.migrations) - 1; i >= 0 && count < steps; i-- {
+		m := mg.migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		if err := mg.run(ctx, m.Down, removeMigration, m.Version); err != nil {
+			return count, fmt.Errorf("failed to revert migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Status returns every known migration with the time it was applied, if it was
+func (mg *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(mg.migrations))
+	for _, m := range mg.migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status.Applied_at = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
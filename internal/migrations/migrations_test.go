@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+// Tables as they were before migrations existed, the baseline the first
+// migrations adopt
+const preMigrationSchema = `
+	CREATE TABLE projects (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		name        TEXT     NOT NULL,
+		description TEXT     NOT NULL DEFAULT '',
+		created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE milestones (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		title          TEXT     NOT NULL,
+		milestone_date DATETIME NOT NULL,
+		description    TEXT     NOT NULL DEFAULT '',
+		body_url       TEXT,
+		github_url     TEXT,
+		image_url      TEXT,
+		milestone_type TEXT     NOT NULL,
+		status         TEXT     NOT NULL DEFAULT 'draft',
+		project_id     INTEGER REFERENCES projects (id) ON DELETE CASCADE
+	);
+	CREATE TABLE api_keys (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		name         TEXT     NOT NULL,
+		key_hash     TEXT     NOT NULL UNIQUE,
+		created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_used_at DATETIME
+	);
+	INSERT INTO projects (name) VALUES ('existing');
+	INSERT INTO milestones (title, milestone_date, milestone_type, status, project_id)
+	VALUES ('shipped', '2024-01-01 00:00:00', 'project_major', 'published', 1);
+	INSERT INTO api_keys (name, key_hash) VALUES ('deploy', 'hash');`
+
+func TestUpAdoptsPreMigrationDatabase(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Conn.ExecContext(ctx, preMigrationSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() on a database that predates migrations: %v", err)
+	}
+	if applied != len(migrator.migrations) {
+		t.Errorf("applied %d migrations, want all %d", applied, len(migrator.migrations))
+	}
+
+	// Existing rows are kept and backfilled, existing projects stay public
+	var status, milestoneStatus string
+	var keys int
+	row := db.Conn.QueryRowContext(ctx, `
+		SELECT p.status, m.status, (SELECT COUNT(*) FROM api_keys)
+		FROM projects p JOIN milestones m ON m.project_id = p.id`)
+	if err := row.Scan(&status, &milestoneStatus, &keys); err != nil {
+		t.Fatal(err)
+	}
+	if status != "published" || milestoneStatus != "published" || keys != 1 {
+		t.Errorf("after Up() project %s, milestone %s, %d api keys, want published, published, 1", status, milestoneStatus, keys)
+	}
+}
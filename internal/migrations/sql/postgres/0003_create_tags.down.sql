@@ -0,0 +1,2 @@
+DROP TABLE milestone_tags;
+DROP TABLE tags;
@@ -0,0 +1,13 @@
+-- May have been created by hand from schema/tags.sql before migrations
+CREATE TABLE IF NOT EXISTS tags (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS milestone_tags (
+    milestone_id INTEGER NOT NULL REFERENCES milestones (id) ON DELETE CASCADE,
+    tag_id       INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (milestone_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS milestone_tags_tag_id_idx ON milestone_tags (tag_id);
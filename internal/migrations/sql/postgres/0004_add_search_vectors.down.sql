@@ -0,0 +1,4 @@
+DROP INDEX milestones_search_vector_idx;
+DROP INDEX projects_search_vector_idx;
+ALTER TABLE milestones DROP COLUMN search_vector;
+ALTER TABLE projects DROP COLUMN search_vector;
@@ -0,0 +1,2 @@
+DROP INDEX projects_status_idx;
+ALTER TABLE projects DROP COLUMN status;
@@ -0,0 +1,5 @@
+-- Existing projects were all public, only new ones start out as drafts
+ALTER TABLE projects ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE projects ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX projects_status_idx ON projects (status);
@@ -0,0 +1,2 @@
+DROP TABLE milestone_status_transitions;
+ALTER TABLE milestones DROP CONSTRAINT milestones_status_check;
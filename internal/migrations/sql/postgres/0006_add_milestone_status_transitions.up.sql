@@ -0,0 +1,16 @@
+UPDATE milestones SET status = 'draft'
+WHERE status NOT IN ('draft', 'review', 'published', 'archived');
+
+ALTER TABLE milestones ADD CONSTRAINT milestones_status_check
+    CHECK (status IN ('draft', 'review', 'published', 'archived'));
+
+CREATE TABLE milestone_status_transitions (
+    id              SERIAL PRIMARY KEY,
+    milestone_id    INTEGER     NOT NULL REFERENCES milestones (id) ON DELETE CASCADE,
+    from_status     TEXT,
+    to_status       TEXT        NOT NULL,
+    actor           TEXT,
+    transitioned_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX milestone_status_transitions_milestone_id_idx ON milestone_status_transitions (milestone_id);
@@ -0,0 +1,13 @@
+-- No foreign key, the history of a purged row is kept
+CREATE TABLE revisions (
+    id           SERIAL PRIMARY KEY,
+    entity_type  TEXT        NOT NULL CHECK (entity_type IN ('project', 'milestone')),
+    entity_id    INTEGER     NOT NULL,
+    action       TEXT        NOT NULL,
+    before_state JSONB,
+    after_state  JSONB,
+    actor        TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX revisions_entity_idx ON revisions (entity_type, entity_id, id);
@@ -0,0 +1,2 @@
+ALTER TABLE milestones DROP COLUMN version;
+ALTER TABLE projects DROP COLUMN version;
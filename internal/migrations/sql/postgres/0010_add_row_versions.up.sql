@@ -0,0 +1,3 @@
+-- Incremented by every change, clients send it back in If-Match to detect concurrent edits
+ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE milestones ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- Set by every change, public responses derive Last-Modified from it
+ALTER TABLE projects ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE milestones ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE projects SET updated_at = created_at;
+
+CREATE INDEX projects_updated_at_idx ON projects (updated_at);
+CREATE INDEX milestones_updated_at_idx ON milestones (updated_at);
@@ -0,0 +1,4 @@
+DROP TRIGGER milestones_notify_change ON milestones;
+DROP TRIGGER projects_notify_change ON projects;
+
+DROP FUNCTION notify_portfolio_change();
@@ -0,0 +1,30 @@
+-- Tells every backend instance listening on portfolio_changes which rows
+-- changed, so they can drop what they cached about them. Milestones also
+-- name the projects they left and joined, whose cached copies list them.
+CREATE FUNCTION notify_portfolio_change() RETURNS trigger AS $$
+DECLARE
+    payload JSON;
+BEGIN
+    IF TG_TABLE_NAME = 'milestones' THEN
+        payload := json_build_object(
+            'entity', 'milestone',
+            'id', COALESCE(NEW.id, OLD.id),
+            'project_ids', json_build_array(OLD.project_id, NEW.project_id));
+    ELSE
+        payload := json_build_object(
+            'entity', 'project',
+            'id', COALESCE(NEW.id, OLD.id));
+    END IF;
+
+    PERFORM pg_notify('portfolio_changes', payload::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER projects_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON projects
+    FOR EACH ROW EXECUTE FUNCTION notify_portfolio_change();
+
+CREATE TRIGGER milestones_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON milestones
+    FOR EACH ROW EXECUTE FUNCTION notify_portfolio_change();
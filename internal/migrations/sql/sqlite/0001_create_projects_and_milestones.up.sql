@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT     NOT NULL,
+    description TEXT     NOT NULL DEFAULT '',
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS milestones (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    title          TEXT     NOT NULL,
+    milestone_date DATETIME NOT NULL,
+    description    TEXT     NOT NULL DEFAULT '',
+    body_url       TEXT,
+    github_url     TEXT,
+    image_url      TEXT,
+    milestone_type TEXT     NOT NULL
+        CHECK (milestone_type IN ('project_major', 'project_minor', 'education', 'career')),
+    status         TEXT     NOT NULL DEFAULT 'draft',
+    project_id     INTEGER REFERENCES projects (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS milestones_project_id_idx ON milestones (project_id);
+CREATE INDEX IF NOT EXISTS milestones_status_date_idx ON milestones (status, milestone_date);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS milestone_tags (
+    milestone_id INTEGER NOT NULL REFERENCES milestones (id) ON DELETE CASCADE,
+    tag_id       INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (milestone_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS milestone_tags_tag_id_idx ON milestone_tags (tag_id);
@@ -0,0 +1 @@
+-- SQLite searches with LIKE, there are no search vectors to maintain
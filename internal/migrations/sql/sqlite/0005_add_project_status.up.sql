@@ -0,0 +1,5 @@
+-- Existing projects were all public. SQLite cannot change a column default
+-- afterwards, new projects always get an explicit status from the dao.
+ALTER TABLE projects ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+
+CREATE INDEX projects_status_idx ON projects (status);
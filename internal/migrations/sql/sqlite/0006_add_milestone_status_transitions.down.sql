@@ -0,0 +1 @@
+DROP TABLE milestone_status_transitions;
@@ -0,0 +1,14 @@
+-- SQLite cannot add a CHECK constraint to an existing table, the dao validates statuses
+UPDATE milestones SET status = 'draft'
+WHERE status NOT IN ('draft', 'review', 'published', 'archived');
+
+CREATE TABLE milestone_status_transitions (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    milestone_id    INTEGER  NOT NULL REFERENCES milestones (id) ON DELETE CASCADE,
+    from_status     TEXT,
+    to_status       TEXT     NOT NULL,
+    actor           TEXT,
+    transitioned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX milestone_status_transitions_milestone_id_idx ON milestone_status_transitions (milestone_id);
@@ -0,0 +1,2 @@
+DROP INDEX milestones_publish_at_idx;
+ALTER TABLE milestones DROP COLUMN publish_at;
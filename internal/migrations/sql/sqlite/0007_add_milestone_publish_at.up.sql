@@ -0,0 +1,3 @@
+ALTER TABLE milestones ADD COLUMN publish_at DATETIME;
+
+CREATE INDEX milestones_publish_at_idx ON milestones (publish_at) WHERE publish_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Rows still in the trash would reappear, purge them instead
+DELETE FROM milestones WHERE deleted_at IS NOT NULL;
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+
+DROP INDEX milestones_deleted_at_idx;
+DROP INDEX projects_deleted_at_idx;
+ALTER TABLE milestones DROP COLUMN deleted_at;
+ALTER TABLE projects DROP COLUMN deleted_at;
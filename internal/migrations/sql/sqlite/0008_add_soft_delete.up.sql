@@ -0,0 +1,6 @@
+-- Deleted rows stay in the trash until restored or purged
+ALTER TABLE projects ADD COLUMN deleted_at DATETIME;
+ALTER TABLE milestones ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX projects_deleted_at_idx ON projects (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX milestones_deleted_at_idx ON milestones (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX milestones_updated_at_idx;
+DROP INDEX projects_updated_at_idx;
+
+ALTER TABLE milestones DROP COLUMN updated_at;
+ALTER TABLE projects DROP COLUMN updated_at;
@@ -0,0 +1,11 @@
+-- Set by every change, public responses derive Last-Modified from it. SQLite
+-- cannot add a column defaulting to CURRENT_TIMESTAMP, new rows always get an
+-- explicit value from the dao.
+ALTER TABLE projects ADD COLUMN updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+ALTER TABLE milestones ADD COLUMN updated_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+UPDATE projects SET updated_at = created_at;
+UPDATE milestones SET updated_at = CURRENT_TIMESTAMP;
+
+CREATE INDEX projects_updated_at_idx ON projects (updated_at);
+CREATE INDEX milestones_updated_at_idx ON milestones (updated_at);
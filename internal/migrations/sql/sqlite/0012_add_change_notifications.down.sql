@@ -0,0 +1,2 @@
+-- SQLite databases are only ever opened by a single backend instance, there
+-- are no other instances to notify of changes
@@ -0,0 +1,34 @@
+-- events is a comma separated list of event types, e.g. milestone.published, or *
+CREATE TABLE webhooks (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    url        TEXT     NOT NULL,
+    secret     TEXT     NOT NULL,
+    events     TEXT     NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deliveries waiting to be sent, rows are removed once delivered
+CREATE TABLE webhook_deliveries (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id      INTEGER  NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event           TEXT     NOT NULL,
+    payload         TEXT     NOT NULL,
+    attempts        INTEGER  NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error      TEXT,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_next_attempt_at_idx ON webhook_deliveries (next_attempt_at);
+
+-- Deliveries that failed every attempt, kept until replayed
+CREATE TABLE webhook_dead_letters (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER  NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event      TEXT     NOT NULL,
+    payload    TEXT     NOT NULL,
+    attempts   INTEGER  NOT NULL,
+    last_error TEXT     NOT NULL,
+    created_at DATETIME NOT NULL,
+    failed_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
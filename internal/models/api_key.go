@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// API key used to authenticate write access. Only the hash of the key is stored.
+type ApiKey struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Key_hash     string     `json:"-"`
+	Created_at   time.Time  `json:"created_at"`
+	Last_used_at *time.Time `json:"last_used_at"`
+}
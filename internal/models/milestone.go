@@ -12,17 +12,75 @@ const (
 	Career    Milestone_Type = "career"
 )
 
+// Reports whether t is one of the known milestone types
+func (t Milestone_Type) Valid() bool {
+	switch t {
+	case Major, Minor, Education, Career:
+		return true
+	}
+	return false
+}
+
+// Enum for the publishing status of a milestone
+type Milestone_Status string
+
+const (
+	Draft     Milestone_Status = "draft"
+	Review    Milestone_Status = "review"
+	Published Milestone_Status = "published"
+	Archived  Milestone_Status = "archived"
+)
+
+// Allowed status changes, staying in the same status is always allowed
+var statusTransitions = map[Milestone_Status][]Milestone_Status{
+	Draft:     {Review, Published, Archived},
+	Review:    {Draft, Published, Archived},
+	Published: {Review, Archived},
+	Archived:  {Review, Published},
+}
+
+// Reports whether s is one of the known statuses
+func (s Milestone_Status) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// Reports whether a milestone may move from status s to next
+func (s Milestone_Status) CanTransitionTo(next Milestone_Status) bool {
+	if s == next {
+		return next.Valid()
+	}
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// A recorded change of a milestone's status. From is empty for the initial status.
+type Status_Transition struct {
+	ID              int              `json:"id"`
+	Milestone_id    int              `json:"milestone_id"`
+	From            Milestone_Status `json:"from"`
+	To              Milestone_Status `json:"to"`
+	Actor           string           `json:"actor"`
+	Transitioned_at time.Time        `json:"transitioned_at"`
+}
+
 type Milestone struct {
-	ID             int            `json:"id"`
-	Title          string         `json:"title"`
-	Milestone_date time.Time      `json:"milestone_date"`
-	Description    string         `json:"description"`
-	Body_url       string         `json:"body_url"`
-	Github_url     string         `json:"github_url"`
-	Image_url      string         `json:"image_url"`
-	Milestone_type Milestone_Type `json:"milestone_type"`
-	Status         string         `json:"status"`
-	Project_id     int            `json:"project_id"`
-
-	Tags []string
+	ID             int              `json:"id"`
+	Title          string           `json:"title"`
+	Milestone_date time.Time        `json:"milestone_date"`
+	Description    string           `json:"description"`
+	Body_url       string           `json:"body_url"`
+	Github_url     string           `json:"github_url"`
+	Image_url      string           `json:"image_url"`
+	Milestone_type Milestone_Type   `json:"milestone_type"`
+	Status         Milestone_Status `json:"status"`
+	Project_id     int              `json:"project_id"`
+	Publish_at     *time.Time       `json:"publish_at"` // hidden from the public until then, cleared once published and passed
+	Version        int              `json:"version"`    // incremented by every change
+
+	Tags []string `json:"tags"`
 }
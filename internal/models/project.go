@@ -1,17 +1,46 @@
 package models
 
 import (
+	"encoding/json"
 	"sort"
 	"time"
 )
 
+// Enum for the publishing status of a project, only published projects are public
+type Project_Status string
+
+const (
+	Project_Draft     Project_Status = "draft"
+	Project_Published Project_Status = "published"
+)
+
+// Reports whether s is one of the known project statuses
+func (s Project_Status) Valid() bool {
+	switch s {
+	case Project_Draft, Project_Published:
+		return true
+	}
+	return false
+}
+
 type Project struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Created_at  time.Time `json:"created_at"`
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Status      Project_Status `json:"status"`
+	Created_at  time.Time      `json:"created_at"`
+	Version     int            `json:"version"` // incremented by every change to the project itself
+
+	Milestones []Milestone `json:"milestones"`
+}
 
-	Milestones []Milestone
+// Includes the ranked tags of the project's milestones in the JSON output
+func (p Project) MarshalJSON() ([]byte, error) {
+	type project Project // drops the MarshalJSON method to avoid recursion
+	return json.Marshal(struct {
+		project
+		Tags []string `json:"tags"`
+	}{project(p), p.tags()})
 }
 
 // Returns a slice of tagnames (strings) sorted by number of occurrences in descending order
@@ -24,14 +53,17 @@ func (p Project) tags() []string {
 		}
 	}
 
-	// Create a slice of tag names and sort by count (descending)
+	// Create a slice of tag names and sort by count (descending), then name for stable output
 	tagNames := make([]string, 0, len(tags))
 	for tag := range tags {
 		tagNames = append(tagNames, tag)
 	}
 
 	sort.Slice(tagNames, func(i, j int) bool {
-		return tags[tagNames[i]] > tags[tagNames[j]]
+		if tags[tagNames[i]] != tags[tagNames[j]] {
+			return tags[tagNames[i]] > tags[tagNames[j]]
+		}
+		return tagNames[i] < tagNames[j]
 	})
 
 	return tagNames
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestProjectJSON(t *testing.T) {
+	p := Project{
+		ID:   1,
+		Name: "portfolio",
+		Milestones: []Milestone{
+			{ID: 1, Tags: []string{"go", "sql"}},
+			{ID: 2, Tags: []string{"sql"}},
+		},
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"id", "name", "description", "status", "created_at", "version", "milestones", "tags"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("missing key %q in %s", key, body)
+		}
+	}
+	if _, ok := fields["Milestones"]; ok {
+		t.Errorf("milestones encoded under their Go field name in %s", body)
+	}
+
+	var tags []string
+	if err := json.Unmarshal(fields["tags"], &tags); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sql", "go"}; !slices.Equal(tags, want) {
+		t.Errorf("tags = %v, want %v ranked by use", tags, want)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A recorded change of a project or milestone. Before is null when the row
+// did not exist or was in the trash, After is null when it no longer exists
+// outside the trash.
+type Revision struct {
+	ID          int             `json:"id"`
+	Entity_type string          `json:"entity_type"` // project or milestone
+	Entity_id   int             `json:"entity_id"`
+	Action      string          `json:"action"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	Actor       string          `json:"actor"`
+	Created_at  time.Time       `json:"created_at"`
+}
+
+// A field that differs between two revisions, null if absent from one of them
+type Field_Change struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
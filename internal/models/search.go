@@ -0,0 +1,10 @@
+package models
+
+// A project or milestone matching a search query
+type SearchResult struct {
+	Kind    string  `json:"kind"` // "project" or "milestone"
+	ID      int     `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"` // HTML escaped, matches wrapped in <mark>
+	Rank    float64 `json:"rank"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// A tag along with the number of published milestones using it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// A soft deleted project or milestone awaiting restore or purge
+type Trash_Item struct {
+	Kind       string    `json:"kind"` // project or milestone
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`      // name of a project
+	Project_id int       `json:"project_id"` // milestones only, 0 if none
+	Deleted_at time.Time `json:"deleted_at"`
+}
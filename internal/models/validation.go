@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const maxTitleLength = 200
+
+// A problem with a single field of a payload
+type Field_Error struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Every field level problem found in a payload
+type Validation_Errors []Field_Error
+
+func (e Validation_Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a problem with field
+func (e *Validation_Errors) Add(field, message string) {
+	*e = append(*e, Field_Error{field, message})
+}
+
+// Prefixed returns a copy of e with every field nested under prefix, e.g. milestones[0].title
+func (e Validation_Errors) Prefixed(prefix string) Validation_Errors {
+	prefixed := make(Validation_Errors, len(e))
+	for i, fe := range e {
+		prefixed[i] = Field_Error{prefix + "." + fe.Field, fe.Message}
+	}
+	return prefixed
+}
+
+// Validate checks the milestone's fields. An empty status is allowed, it
+// defaults to draft when the milestone is created.
+func (m Milestone) Validate() Validation_Errors {
+	var errs Validation_Errors
+
+	switch title := strings.TrimSpace(m.Title); {
+	case title == "":
+		errs.Add("title", "must not be empty")
+	case len(title) > maxTitleLength:
+		errs.Add("title", fmt.Sprintf("must be at most %d characters", maxTitleLength))
+	}
+
+	if m.Milestone_date.IsZero() {
+		errs.Add("milestone_date", "is required")
+	}
+
+	switch {
+	case m.Milestone_type == "":
+		errs.Add("milestone_type", "is required")
+	case !m.Milestone_type.Valid():
+		errs.Add("milestone_type", "must be one of project_major, project_minor, education or career")
+	}
+
+	if m.Status != "" && !m.Status.Valid() {
+		errs.Add("status", "must be one of draft, review, published or archived")
+	}
+
+	validateURL(&errs, "body_url", m.Body_url)
+	validateURL(&errs, "github_url", m.Github_url)
+	validateURL(&errs, "image_url", m.Image_url)
+
+	// Project milestones belong to a project, education and career ones never do
+	switch {
+	case m.Project_id < 0:
+		errs.Add("project_id", "must not be negative")
+	case (m.Milestone_type == Major || m.Milestone_type == Minor) && m.Project_id == 0:
+		errs.Add("project_id", "is required for project_major and project_minor milestones")
+	case (m.Milestone_type == Education || m.Milestone_type == Career) && m.Project_id != 0:
+		errs.Add("project_id", "must be empty for education and career milestones")
+	}
+
+	return errs
+}
+
+// Validate checks the project's fields, ignoring its milestones
+func (p Project) Validate() Validation_Errors {
+	var errs Validation_Errors
+
+	switch name := strings.TrimSpace(p.Name); {
+	case name == "":
+		errs.Add("name", "must not be empty")
+	case len(name) > maxTitleLength:
+		errs.Add("name", fmt.Sprintf("must be at most %d characters", maxTitleLength))
+	}
+
+	if !p.Status.Valid() {
+		errs.Add("status", "must be one of draft or published")
+	}
+
+	return errs
+}
+
+// Validate checks the webhook's url and event types
+func (w Webhook) Validate() Validation_Errors {
+	var errs Validation_Errors
+
+	if w.Url == "" {
+		errs.Add("url", "is required")
+	}
+	validateURL(&errs, "url", w.Url)
+
+	if len(w.Events) == 0 {
+		errs.Add("events", "must name at least one event type")
+	}
+	for i, e := range w.Events {
+		if e != "*" && !slices.Contains(Webhook_Event_Types, e) {
+			errs.Add(fmt.Sprintf("events[%d]", i), "must be * or one of "+strings.Join(Webhook_Event_Types, ", "))
+		}
+	}
+
+	return errs
+}
+
+// validateURL accepts an empty value or an absolute http(s) URL
+func validateURL(errs *Validation_Errors, field, value string) {
+	if value == "" {
+		return
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.Add(field, "must be an absolute http or https URL")
+	}
+}
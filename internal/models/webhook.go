@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types a webhook can subscribe to, matching the types of the event
+// stream. "*" subscribes to every type.
+var Webhook_Event_Types = []string{
+	"project.created", "project.updated", "project.published", "project.deleted",
+	"milestone.created", "milestone.updated", "milestone.published", "milestone.deleted",
+}
+
+// Endpoint receiving a signed POST for every event it subscribed to. The
+// secret is only ever returned when the webhook is created.
+type Webhook struct {
+	ID         int       `json:"id"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"-"`
+	Events     []string  `json:"events"`
+	Created_at time.Time `json:"created_at"`
+}
+
+// Subscribed reports whether the webhook wants events of the given type
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, e := range w.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// A webhook delivery waiting to be sent, or given up on after its last attempt
+type Webhook_Delivery struct {
+	ID              int             `json:"id"`
+	Webhook_id      int             `json:"webhook_id"`
+	Event           string          `json:"event"`
+	Payload         json.RawMessage `json:"payload"`
+	Attempts        int             `json:"attempts"`
+	Last_error      string          `json:"last_error"`
+	Created_at      time.Time       `json:"created_at"`
+	Next_attempt_at *time.Time      `json:"next_attempt_at,omitempty"` // pending deliveries only
+	Failed_at       *time.Time      `json:"failed_at,omitempty"`       // dead letters only
+
+	// Where and how to send a pending delivery
+	Url    string `json:"-"`
+	Secret string `json:"-"`
+}
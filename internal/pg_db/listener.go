@@ -0,0 +1,75 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+
+	// How long the connection may stay silent before it is checked
+	listenerPingInterval = 90 * time.Second
+)
+
+// Listener receives the notifications sent on a channel over a connection of
+// its own, reconnecting with backoff whenever it drops
+type Listener struct {
+	listener *pq.Listener
+	channel  string
+}
+
+// Instantiates a Listener subscribed to channel. Connecting happens in the
+// background, a database that is down delays notifications but is not an error.
+func NewListener(config Pg_Config, channel string) (*Listener, error) {
+	listener := pq.NewListener(connString(config), minReconnectInterval, maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventDisconnected:
+				log.Printf("Lost connection listening on %s: %v", channel, err)
+			case pq.ListenerEventReconnected:
+				log.Printf("Reconnected listening on %s", channel)
+			case pq.ListenerEventConnectionAttemptFailed:
+				log.Printf("Failed to connect listening on %s: %v", channel, err)
+			}
+		})
+
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	return &Listener{listener, channel}, nil
+}
+
+// Run calls notify with the payload of every notification until ctx is
+// cancelled, then closes the connection. Notifications sent while the
+// connection was down are lost, so reset is called after every reconnect.
+func (l *Listener) Run(ctx context.Context, notify func(payload string), reset func()) {
+	defer l.listener.Close()
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.listener.Notify:
+			if n == nil {
+				reset()
+				continue
+			}
+			notify(n.Extra)
+			ticker.Reset(listenerPingInterval)
+		case <-ticker.C:
+			// A failed ping makes the listener notice the dead connection and reconnect
+			go l.listener.Ping()
+		}
+	}
+}
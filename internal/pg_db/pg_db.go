@@ -1,9 +1,11 @@
 package pgdb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/NH-Homelab/portfolio-backend/internal/database"
 	_ "github.com/lib/pq"
 )
 
@@ -21,10 +23,7 @@ type Pg_Config struct {
 
 // Instantiates a PostgresDB connection type
 func NewPostgresDB(config Pg_Config) (*PostgresDB, error) {
-	connstr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.Db_name)
-
-	db, err := sql.Open("postgres", connstr)
+	db, err := sql.Open("postgres", connString(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -37,14 +36,55 @@ func NewPostgresDB(config Pg_Config) (*PostgresDB, error) {
 	return &PostgresDB{Conn: db}, nil
 }
 
+func connString(config Pg_Config) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		config.Host, config.Port, config.User, config.Password, config.Db_name)
+}
+
 func (pg *PostgresDB) Close() error {
 	return pg.Conn.Close()
 }
 
-func (pg *PostgresDB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return pg.Conn.Exec(query, args...)
+func (pg *PostgresDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return pg.Conn.ExecContext(ctx, query, args...)
+}
+
+func (pg *PostgresDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return pg.Conn.QueryContext(ctx, query, args...)
+}
+
+func (pg *PostgresDB) Dialect() database.Dialect {
+	return database.Postgres
+}
+
+func (pg *PostgresDB) BeginTx(ctx context.Context) (database.Tx, error) {
+	tx, err := pg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &PostgresTx{tx}, nil
+}
+
+type PostgresTx struct {
+	tx *sql.Tx
+}
+
+func (t *PostgresTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *PostgresTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *PostgresTx) Dialect() database.Dialect {
+	return database.Postgres
+}
+
+func (t *PostgresTx) Commit() error {
+	return t.tx.Commit()
 }
 
-func (pg *PostgresDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return pg.Conn.Query(query, args...)
+func (t *PostgresTx) Rollback() error {
+	return t.tx.Rollback()
 }
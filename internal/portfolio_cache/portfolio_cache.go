@@ -0,0 +1,267 @@
+package portfoliocache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+// Channel the database triggers notify of every change on, see the
+// add_change_notifications migration
+const ChangesChannel = "portfolio_changes"
+
+// Keys of the cached reads
+const (
+	allProjectsKey            = "projects"
+	allPublishedMilestonesKey = "published_milestones"
+)
+
+func projectKey(id int) string   { return fmt.Sprintf("project:%d", id) }
+func milestoneKey(id int) string { return fmt.Sprintf("milestone:%d", id) }
+
+// CachedDao wraps a PortfolioDao, serving its most frequent reads from memory.
+// Entries are dropped as soon as a committed write changes what they hold, and
+// after ttl at the latest. Reads that depend on the time expire when the next
+// publish_at passes. Every other method goes straight to the PortfolioDao.
+type CachedDao struct {
+	*portfoliodao.PortfolioDao
+
+	ttl        time.Duration // zero disables caching
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is the most recently used entry
+	// Incremented by every invalidation, a read that overlapped one is not stored
+	generation uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// Counters of the cache since it was created
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// Wraps dao with a cache holding at most maxEntries reads for up to ttl each
+func NewCachedDao(dao *portfoliodao.PortfolioDao, ttl time.Duration, maxEntries int) *CachedDao {
+	c := &CachedDao{
+		PortfolioDao: dao,
+		ttl:          ttl,
+		maxEntries:   maxEntries,
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+	}
+	dao.OnChange(c.invalidateChange)
+	return c
+}
+
+// Returns all projects without their milestones, whatever their status
+func (c *CachedDao) GetAllProjects(ctx context.Context) ([]models.Project, error) {
+	projects, err := load(c, allProjectsKey, func() ([]models.Project, error) {
+		return c.PortfolioDao.GetAllProjects(ctx)
+	})
+	return slices.Clone(projects), err
+}
+
+// Returns a project with all of its milestones, whatever their status
+func (c *CachedDao) GetProjectById(ctx context.Context, id int) (*models.Project, error) {
+	project, err := load(c, projectKey(id), func() (*models.Project, error) {
+		return c.PortfolioDao.GetProjectById(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *project
+	copied.Milestones = slices.Clone(project.Milestones)
+	return &copied, nil
+}
+
+// Returns a milestone whatever its status
+func (c *CachedDao) GetMilestoneById(ctx context.Context, id int) (*models.Milestone, error) {
+	milestone, err := load(c, milestoneKey(id), func() (*models.Milestone, error) {
+		return c.PortfolioDao.GetMilestoneById(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *milestone
+	return &copied, nil
+}
+
+// Returns all publicly visible milestones, newest first. Kept until the next
+// scheduled milestone appears at the latest.
+func (c *CachedDao) GetAllPublishedMilestones(ctx context.Context) ([]models.Milestone, error) {
+	milestones, err := loadUntil(c, allPublishedMilestonesKey, func() ([]models.Milestone, time.Time, error) {
+		// Read first, a milestone appearing in between only expires the entry early
+		next, err := c.PortfolioDao.GetNextPublishAt(ctx)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		milestones, err := c.PortfolioDao.GetAllPublishedMilestones(ctx)
+		return milestones, next, err
+	})
+	return slices.Clone(milestones), err
+}
+
+// Stats returns the hit and miss counters and the current number of entries
+func (c *CachedDao) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: c.lru.Len()}
+}
+
+// load returns the cached value of key or stores what fetch returns. Errors,
+// including not found, are never cached. Callers must copy the value before
+// handing it out, the cached one is shared.
+func load[T any](c *CachedDao, key string, fetch func() (T, error)) (T, error) {
+	return loadUntil(c, key, func() (T, time.Time, error) {
+		value, err := fetch()
+		return value, time.Time{}, err
+	})
+}
+
+// loadUntil is load for values that go stale at the time fetch returns along
+// with them, the zero time for never
+func loadUntil[T any](c *CachedDao, key string, fetch func() (T, time.Time, error)) (T, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return e.value.(T), nil
+		}
+		c.remove(el)
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+	value, staleAt, err := fetch()
+	if err != nil || c.ttl <= 0 || c.maxEntries <= 0 {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		// A write committed while fetching, value may predate it
+		return value, nil
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	expires := time.Now().Add(c.ttl)
+	if !staleAt.IsZero() && staleAt.Before(expires) {
+		expires = staleAt
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key, value, expires})
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+
+	return value, nil
+}
+
+// InvalidateNotification drops the entries named by a notification on
+// ChangesChannel, which reports the writes of every backend instance
+func (c *CachedDao) InvalidateNotification(payload string) {
+	var n struct {
+		Entity      string `json:"entity"`
+		ID          int    `json:"id"`
+		Project_ids []int  `json:"project_ids"` // nulls decode as 0
+	}
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("Flushing the cache after an unreadable change notification %q: %v", payload, err)
+		c.Flush()
+		return
+	}
+
+	c.invalidate(n.Entity, n.ID, n.Project_ids)
+}
+
+// Flush drops every entry
+func (c *CachedDao) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// invalidateChange drops the entries a change committed by this instance affects
+func (c *CachedDao) invalidateChange(change portfoliodao.Change) {
+	var projectIDs []int
+	if change.Entity == portfoliodao.EntityMilestone {
+		for _, state := range []json.RawMessage{change.Before, change.After} {
+			var m struct {
+				Project_id int `json:"project_id"`
+			}
+			if len(state) > 0 && json.Unmarshal(state, &m) == nil {
+				projectIDs = append(projectIDs, m.Project_id)
+			}
+		}
+	}
+
+	c.invalidate(change.Entity, change.ID, projectIDs)
+}
+
+// invalidate drops the entries a change of an entity affects. Milestones are
+// listed by the projects they belong to, projectIDs holds those the milestone
+// left and joined, zero for none.
+func (c *CachedDao) invalidate(entity string, id int, projectIDs []int) {
+	keys := []string{allPublishedMilestonesKey}
+
+	switch entity {
+	case portfoliodao.EntityProject:
+		// A project's status decides whether its milestones are public
+		keys = append(keys, allProjectsKey, projectKey(id))
+	case portfoliodao.EntityMilestone:
+		keys = append(keys, milestoneKey(id))
+		for _, projectID := range projectIDs {
+			if projectID != 0 {
+				keys = append(keys, projectKey(projectID))
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// remove drops an entry, c.mu must be held
+func (c *CachedDao) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,76 @@
+package portfoliocache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+func newTestCache(t *testing.T) *CachedDao {
+	t.Helper()
+
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewCachedDao(portfoliodao.NewPortfolioDao(db, 5*time.Second), time.Minute, 100)
+}
+
+// Empty lists must encode as [] like the uncached reads, for hits and misses alike
+func TestEmptyListsStayEmpty(t *testing.T) {
+	c := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		projects, err := c.GetAllProjects(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		milestones, err := c.GetAllPublishedMilestones(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEncodes(t, "GetAllProjects()", projects, "[]")
+		assertEncodes(t, "GetAllPublishedMilestones()", milestones, "[]")
+	}
+
+	id, err := c.CreateProject(ctx, "project", "", models.Project_Draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		project, err := c.GetProjectById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEncodes(t, "GetProjectById().Milestones", project.Milestones, "[]")
+	}
+}
+
+func assertEncodes(t *testing.T, name string, v interface{}, want string) {
+	t.Helper()
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != want {
+		t.Errorf("%s encodes as %s, want %s", name, body, want)
+	}
+}
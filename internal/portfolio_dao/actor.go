@@ -0,0 +1,17 @@
+package portfoliodao
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx naming who performs the writes made with it,
+// used when recording status transitions and revisions
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFromContext returns the actor set by WithActor, or "" if there is none
+func actorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,147 @@
+package portfoliodao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	createApiKey = `
+		INSERT INTO api_keys (name, key_hash)
+		VALUES ($1, $2)
+		RETURNING id`
+	getApiKeyByHash = `
+		SELECT id, name, key_hash, created_at, last_used_at
+		FROM api_keys
+		WHERE key_hash = $1`
+	getAllApiKeys = `
+		SELECT id, name, key_hash, created_at, last_used_at
+		FROM api_keys
+		ORDER BY id`
+	touchApiKey = `
+		UPDATE api_keys
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+	deleteApiKey = `
+		DELETE FROM api_keys
+		WHERE id = $1`
+)
+
+// CreateApiKey stores the hash of a new API key and returns its ID
+func (dao *PortfolioDao) CreateApiKey(ctx context.Context, name, keyHash string) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, createApiKey, name, keyHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create api key: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, fmt.Errorf("failed to get created api key id")
+	}
+
+	var id int
+	if err := rows.Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to scan api key id: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetApiKeyByHash returns the API key matching the given hash
+func (dao *PortfolioDao) GetApiKeyByHash(ctx context.Context, keyHash string) (*models.ApiKey, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getApiKeyByHash, keyHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api key: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, notFound("api key")
+	}
+
+	k, err := scanApiKey(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return k, rows.Err()
+}
+
+// GetAllApiKeys returns every API key, without the key material
+func (dao *PortfolioDao) GetAllApiKeys(ctx context.Context) ([]models.ApiKey, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getAllApiKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.ApiKey, 0)
+	for rows.Next() {
+		k, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys, rows.Err()
+}
+
+// TouchApiKey records that the API key was just used
+func (dao *PortfolioDao) TouchApiKey(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := dao.db.ExecContext(ctx, touchApiKey, id); err != nil {
+		return fmt.Errorf("failed to update api key last use: %w", err)
+	}
+	return nil
+}
+
+// DeleteApiKey revokes an API key by ID
+func (dao *PortfolioDao) DeleteApiKey(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	result, err := dao.db.ExecContext(ctx, deleteApiKey, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound("api key with id %d", id)
+	}
+
+	return nil
+}
+
+func scanApiKey(rows *sql.Rows) (*models.ApiKey, error) {
+	var k models.ApiKey
+	var lastUsed sql.NullTime
+
+	if err := rows.Scan(&k.ID, &k.Name, &k.Key_hash, &k.Created_at, &lastUsed); err != nil {
+		return nil, fmt.Errorf("failed to scan api key row: %w", err)
+	}
+	if lastUsed.Valid {
+		k.Last_used_at = &lastUsed.Time
+	}
+
+	return &k, nil
+}
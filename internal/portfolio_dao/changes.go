@@ -0,0 +1,183 @@
+package portfoliodao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SQL condition for a milestone aliased m counting as public in the changes
+// reported to observers. Same as publicMilestoneCondition, except that a
+// publish_at time keeps the milestone hidden until the scheduler releases it by
+// clearing publish_at, so there is a write to report when it shows up.
+// Scheduled milestones are announced at most one publish interval after the
+// public API starts showing them, never before.
+const announcedMilestoneCondition = publicMilestoneCondition + ` AND m.publish_at IS NULL`
+
+const (
+	isProjectPublic = `
+		SELECT 1 FROM projects
+		WHERE id = $1 AND status = 'published' AND deleted_at IS NULL`
+	isMilestonePublic = `
+		SELECT 1 FROM milestones m
+		WHERE m.id = $1 AND ` + announcedMilestoneCondition
+	getPublicProjectMilestoneIDs = `
+		SELECT m.id FROM milestones m
+		WHERE m.project_id = $1 AND ` + announcedMilestoneCondition
+)
+
+// Change describes a committed write to a project or milestone. A project
+// write that shows or hides its milestones also reports a change of each of
+// them, with equal Before and After as the milestones themselves are unchanged.
+type Change struct {
+	Entity string // EntityProject or EntityMilestone
+	ID     int
+	Action string
+
+	// The entity as it was before and after the write, as recorded on its
+	// revision. Before is nil for creations, After is nil once it is purged.
+	Before json.RawMessage
+	After  json.RawMessage
+
+	// Whether readers of the public API could see the entity before and after
+	// the write, see announcedMilestoneCondition
+	WasPublic bool
+	IsPublic  bool
+}
+
+type observers struct {
+	mu           sync.RWMutex
+	fns          []func(Change)
+	beforeCommit []func(context.Context, *PortfolioDao, []Change) error
+}
+
+// OnChange registers fn to be called with every change once it is committed.
+// fn runs synchronously on the writing goroutine, before the write returns, and
+// must not block.
+func (dao *PortfolioDao) OnChange(fn func(Change)) {
+	dao.observers.mu.Lock()
+	defer dao.observers.mu.Unlock()
+	dao.observers.fns = append(dao.observers.fns, fn)
+}
+
+// BeforeCommit registers fn to be called with the changes of every transaction
+// that made any, inside the transaction right before it commits. An error
+// rolls the transaction back, so whatever fn stores through tx is committed
+// if and only if the changes are.
+func (dao *PortfolioDao) BeforeCommit(fn func(ctx context.Context, tx *PortfolioDao, changes []Change) error) {
+	dao.observers.mu.Lock()
+	defer dao.observers.mu.Unlock()
+	dao.observers.beforeCommit = append(dao.observers.beforeCommit, fn)
+}
+
+// changed reports a change, deferred until commit when bound to a transaction.
+// Writes that record changes always run inside one, so BeforeCommit sees them.
+func (dao *PortfolioDao) changed(c Change) {
+	if dao.pending != nil {
+		*dao.pending = append(*dao.pending, c)
+		return
+	}
+	dao.observers.notify([]Change{c})
+}
+
+func (o *observers) committing(ctx context.Context, tx *PortfolioDao, changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, fn := range o.beforeCommit {
+		if err := fn(ctx, tx, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *observers) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, c := range changes {
+		for _, fn := range o.fns {
+			fn(c)
+		}
+	}
+}
+
+// isPublic reports whether readers of the public API can see an entity
+func (dao *PortfolioDao) isPublic(ctx context.Context, entity string, id int) (bool, error) {
+	query := isProjectPublic
+	if entity == EntityMilestone {
+		query = isMilestonePublic
+	}
+
+	ids, err := dao.queryIDs(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to query visibility of %s %d: %w", entity, id, err)
+	}
+	return len(ids) > 0, nil
+}
+
+// trackMilestoneVisibility runs fn, a write to a project, and reports a change
+// of each of the project's milestones it showed or hid. No revisions are
+// recorded, the milestones did not change.
+func (dao *PortfolioDao) trackMilestoneVisibility(ctx context.Context, projectID int, fn func() error) error {
+	before, err := dao.queryIDs(ctx, getPublicProjectMilestoneIDs, projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	after, err := dao.queryIDs(ctx, getPublicProjectMilestoneIDs, projectID)
+	if err != nil {
+		return err
+	}
+
+	wasPublic := make(map[int]bool, len(before))
+	for _, id := range before {
+		wasPublic[id] = true
+	}
+	isPublic := make(map[int]bool, len(after))
+	for _, id := range after {
+		isPublic[id] = true
+	}
+
+	var flipped []int
+	for _, id := range before {
+		if !isPublic[id] {
+			flipped = append(flipped, id)
+		}
+	}
+	for _, id := range after {
+		if !wasPublic[id] {
+			flipped = append(flipped, id)
+		}
+	}
+
+	for _, id := range flipped {
+		state, err := dao.snapshot(ctx, EntityMilestone, id, false)
+		if err != nil {
+			return err
+		}
+		dao.changed(Change{
+			Entity:    EntityMilestone,
+			ID:        id,
+			Action:    ActionUpdate,
+			Before:    state,
+			After:     state,
+			WasPublic: wasPublic[id],
+			IsPublic:  isPublic[id],
+		})
+	}
+
+	return nil
+}
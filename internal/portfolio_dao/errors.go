@@ -0,0 +1,58 @@
+package portfoliodao
+
+import (
+	"fmt"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+// General kinds of failure, check for them with errors.Is. Any other error
+// means the database itself failed.
+var (
+	ErrNotFound   = &kindError{"not found", nil}
+	ErrConflict   = &kindError{"conflict", nil}
+	ErrValidation = &kindError{"validation failed", nil}
+
+	// The row changed since the version the caller based its write on
+	ErrPreconditionFailed = &kindError{"precondition failed", nil}
+)
+
+// Returned by the update methods when the update struct has no fields set
+var ErrNoFieldsToUpdate = &kindError{"no fields to update", ErrValidation}
+
+// kindError is a sentinel error that also matches the general kind it belongs to
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// notFound returns an ErrNotFound error for the described row
+func notFound(format string, args ...interface{}) error {
+	return fmt.Errorf("%s %w", fmt.Sprintf(format, args...), ErrNotFound)
+}
+
+// conflict returns an ErrConflict error with the given message
+func conflict(format string, args ...interface{}) error {
+	return &kindError{fmt.Sprintf(format, args...), ErrConflict}
+}
+
+// preconditionFailed returns an ErrPreconditionFailed error with the given message
+func preconditionFailed(format string, args ...interface{}) error {
+	return &kindError{fmt.Sprintf(format, args...), ErrPreconditionFailed}
+}
+
+// validationError wraps field level errors in ErrValidation, nil if there are none
+func validationError(errs models.Validation_Errors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrValidation, errs)
+}
@@ -0,0 +1,62 @@
+package portfoliodao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+// newTestDao returns a DAO backed by a migrated in-memory database
+func newTestDao(t *testing.T) *PortfolioDao {
+	t.Helper()
+
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewPortfolioDao(db, 5*time.Second)
+}
+
+// createTestProject creates a project with the given status and returns its ID
+func createTestProject(t *testing.T, dao *PortfolioDao, status models.Project_Status) int {
+	t.Helper()
+
+	id, err := dao.CreateProject(context.Background(), "project", "", status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// createTestMilestone creates a project milestone with the given status and
+// tags and returns its ID
+func createTestMilestone(t *testing.T, dao *PortfolioDao, projectID int, status models.Milestone_Status, tags ...string) int {
+	t.Helper()
+
+	id, err := dao.CreateMilestone(context.Background(), models.Milestone{
+		Title:          "milestone",
+		Milestone_date: time.Now().UTC().Add(-time.Hour),
+		Milestone_type: models.Minor,
+		Status:         status,
+		Project_id:     projectID,
+		Tags:           tags,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
@@ -0,0 +1,112 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Each query returns at most one time. They are kept apart instead of taking
+// the greatest in SQL, SQLite only scans times from plain DATETIME columns.
+// Trashed rows count, moving a row to the trash changes what readers see.
+const (
+	getProjectsUpdatedAt = `
+		SELECT updated_at FROM projects
+		ORDER BY updated_at DESC LIMIT 1`
+	getMilestonesUpdatedAt = `
+		SELECT updated_at FROM milestones
+		ORDER BY updated_at DESC LIMIT 1`
+	// A published milestone appears once its publish_at passes, without any write
+	getMilestonesPublishedAt = `
+		SELECT publish_at FROM milestones
+		WHERE publish_at IS NOT NULL AND publish_at <= CURRENT_TIMESTAMP
+		ORDER BY publish_at DESC LIMIT 1`
+	// Draft and review milestones only appear through the scheduler's write
+	getNextPublishAt = `
+		SELECT publish_at FROM milestones
+		WHERE status = 'published' AND deleted_at IS NULL AND publish_at > CURRENT_TIMESTAMP
+		ORDER BY publish_at LIMIT 1`
+	getMilestoneUpdatedAt = `
+		SELECT updated_at FROM milestones
+		WHERE id = $1`
+	getMilestonePublishedAt = `
+		SELECT publish_at FROM milestones
+		WHERE id = $1 AND publish_at IS NOT NULL AND publish_at <= CURRENT_TIMESTAMP`
+	getProjectUpdatedAt = `
+		SELECT updated_at FROM projects
+		WHERE id = $1`
+	getProjectMilestonesUpdatedAt = `
+		SELECT updated_at FROM milestones
+		WHERE project_id = $1
+		ORDER BY updated_at DESC LIMIT 1`
+	getProjectMilestonesPublishedAt = `
+		SELECT publish_at FROM milestones
+		WHERE project_id = $1 AND publish_at IS NOT NULL AND publish_at <= CURRENT_TIMESTAMP
+		ORDER BY publish_at DESC LIMIT 1`
+)
+
+// GetLastModified returns when any project or milestone last changed in a way
+// visible to readers, or the zero time if there are none
+func (dao *PortfolioDao) GetLastModified(ctx context.Context) (time.Time, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.latest(ctx, []string{getProjectsUpdatedAt, getMilestonesUpdatedAt, getMilestonesPublishedAt})
+}
+
+// GetMilestoneLastModified returns when a milestone last changed in a way
+// visible to readers, or the zero time if it does not exist
+func (dao *PortfolioDao) GetMilestoneLastModified(ctx context.Context, id int) (time.Time, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.latest(ctx, []string{getMilestoneUpdatedAt, getMilestonePublishedAt}, id)
+}
+
+// GetProjectLastModified returns when a project or one of its milestones last
+// changed in a way visible to readers, or the zero time if it does not exist
+func (dao *PortfolioDao) GetProjectLastModified(ctx context.Context, id int) (time.Time, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.latest(ctx, []string{getProjectUpdatedAt, getProjectMilestonesUpdatedAt, getProjectMilestonesPublishedAt}, id)
+}
+
+// GetNextPublishAt returns when the next published milestone appears because
+// its publish_at passes, or the zero time if none is scheduled. Until then
+// only writes change what readers see.
+func (dao *PortfolioDao) GetNextPublishAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.latest(ctx, []string{getNextPublishAt})
+}
+
+// latest runs queries returning at most one time each and returns the latest of them
+func (dao *PortfolioDao) latest(ctx context.Context, queries []string, args ...interface{}) (time.Time, error) {
+	var latest time.Time
+	for _, query := range queries {
+		rows, err := dao.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to query last modified time: %w", err)
+		}
+
+		var t time.Time
+		if rows.Next() {
+			err = rows.Scan(&t)
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+		rows.Close()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to scan last modified time: %w", err)
+		}
+
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,51 @@
+package portfoliodao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+func TestGetProjectLastModified(t *testing.T) {
+	dao := newTestDao(t)
+	ctx := context.Background()
+
+	from := createTestProject(t, dao, models.Project_Published)
+	to := createTestProject(t, dao, models.Project_Published)
+	milestone := createTestMilestone(t, dao, from, models.Published)
+
+	before, err := dao.GetProjectLastModified(ctx, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.IsZero() {
+		t.Fatal("GetProjectLastModified() of an existing project is zero")
+	}
+
+	// CURRENT_TIMESTAMP has whole seconds in SQLite
+	time.Sleep(1100 * time.Millisecond)
+	if err := dao.UpdateMilestone(ctx, milestone, 0, MilestoneUpdate{ProjectID: &to}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both projects' milestones changed
+	for _, id := range []int{from, to} {
+		after, err := dao.GetProjectLastModified(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !after.After(before) {
+			t.Errorf("GetProjectLastModified(%d) = %v after moving a milestone, want later than %v", id, after, before)
+		}
+	}
+
+	missing, err := dao.GetProjectLastModified(ctx, 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !missing.IsZero() {
+		t.Errorf("GetProjectLastModified() of a missing project = %v, want zero", missing)
+	}
+}
@@ -0,0 +1,103 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+
+	getPublishedMilestonesPage = `
+		SELECT m.id, m.title, m.milestone_date, m.description, m.body_url,
+			   m.github_url, m.image_url, m.milestone_type, m.status, m.project_id, m.publish_at, m.version
+		FROM milestones m
+		WHERE %s
+		ORDER BY m.milestone_date %s, m.id %s
+		LIMIT %d`
+)
+
+// Position of the last milestone of a page, the next page starts after it
+type MilestoneCursor struct {
+	Milestone_date time.Time
+	ID             int
+}
+
+// Filters and ordering for GetPublishedMilestonesPage, nil fields are not filtered on
+type MilestoneFilter struct {
+	Limit     int
+	After     *MilestoneCursor
+	Type      *models.Milestone_Type
+	ProjectID *int
+	From      *time.Time // inclusive
+	To        *time.Time // inclusive
+	Ascending bool
+}
+
+// GetPublishedMilestonesPage returns one page of published milestones using
+// keyset pagination on (milestone_date, id), along with the cursor of the
+// next page or nil if this is the last page
+func (dao *PortfolioDao) GetPublishedMilestonesPage(ctx context.Context, filter MilestoneFilter) ([]models.Milestone, *MilestoneCursor, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	conditions := []string{publicMilestoneCondition}
+	var args []interface{}
+	addCondition := func(format string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf(format, placeholders...))
+	}
+
+	if filter.Type != nil {
+		addCondition("m.milestone_type = %s", *filter.Type)
+	}
+	if filter.ProjectID != nil {
+		addCondition("m.project_id = %s", *filter.ProjectID)
+	}
+	if filter.From != nil {
+		addCondition("m.milestone_date >= %s", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("m.milestone_date <= %s", *filter.To)
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		addCondition("(m.milestone_date, m.id) "+comparison+" (%s, %s)", filter.After.Milestone_date, filter.After.ID)
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	query := fmt.Sprintf(getPublishedMilestonesPage, strings.Join(conditions, " AND "), direction, direction, limit+1)
+	milestones, err := dao.queryMilestones(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query published milestones: %w", err)
+	}
+
+	if len(milestones) <= limit {
+		return milestones, nil, nil
+	}
+
+	milestones = milestones[:limit]
+	last := milestones[limit-1]
+	return milestones, &MilestoneCursor{Milestone_date: last.Milestone_date, ID: last.ID}, nil
+}
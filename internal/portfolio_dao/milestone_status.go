@@ -0,0 +1,205 @@
+package portfoliodao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	getMilestoneStatus = `
+		SELECT status
+		FROM milestones
+		WHERE id = $1 AND deleted_at IS NULL`
+	updateMilestoneStatus = `
+		UPDATE milestones
+		SET status = $1, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3`
+	recordStatusTransition = `
+		INSERT INTO milestone_status_transitions (milestone_id, from_status, to_status, actor)
+		VALUES ($1, $2, $3, $4)`
+	// Published milestones are due too, their publish_at still has to be
+	// cleared to announce them, see announcedMilestoneCondition
+	getDueMilestones = `
+		SELECT id
+		FROM milestones
+		WHERE status IN ('draft', 'review', 'published') AND deleted_at IS NULL
+			AND publish_at IS NOT NULL AND publish_at <= CURRENT_TIMESTAMP
+		ORDER BY publish_at`
+	releaseMilestone = `
+		UPDATE milestones
+		SET publish_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+	getStatusTransitions = `
+		SELECT id, milestone_id, from_status, to_status, actor, transitioned_at
+		FROM milestone_status_transitions
+		WHERE milestone_id = $1
+		ORDER BY transitioned_at, id`
+)
+
+var (
+	ErrInvalidStatus           = &kindError{"invalid milestone status", ErrValidation}
+	ErrInvalidStatusTransition = &kindError{"invalid milestone status transition", ErrConflict}
+)
+
+// validateStatus rejects unknown statuses before they reach the database
+func validateStatus(status models.Milestone_Status) error {
+	if !status.Valid() {
+		return fmt.Errorf("%w %q, must be one of draft, review, published or archived", ErrInvalidStatus, status)
+	}
+	return nil
+}
+
+// transitionMilestoneStatus moves a milestone to a new status if the state
+// machine allows it and records the transition. Must run inside a transaction.
+func (dao *PortfolioDao) transitionMilestoneStatus(ctx context.Context, id int, next models.Milestone_Status) error {
+	if err := validateStatus(next); err != nil {
+		return err
+	}
+
+	rows, err := dao.db.QueryContext(ctx, getMilestoneStatus, id)
+	if err != nil {
+		return fmt.Errorf("failed to query milestone status: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return notFound("milestone with id %d", id)
+	}
+
+	var current models.Milestone_Status
+	if err := rows.Scan(&current); err != nil {
+		return fmt.Errorf("failed to scan milestone status: %w", err)
+	}
+	rows.Close()
+
+	if current == next {
+		return nil
+	}
+	if !current.CanTransitionTo(next) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, current, next)
+	}
+
+	// Only update if nobody changed the status since it was read
+	result, err := dao.db.ExecContext(ctx, updateMilestoneStatus, next, id, current)
+	if err != nil {
+		return fmt.Errorf("failed to update milestone status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: milestone %d status changed concurrently", ErrInvalidStatusTransition, id)
+	}
+
+	return dao.recordStatusTransition(ctx, id, current, next)
+}
+
+// recordStatusTransition stores who moved a milestone between two statuses
+func (dao *PortfolioDao) recordStatusTransition(ctx context.Context, id int, from, to models.Milestone_Status) error {
+	_, err := dao.db.ExecContext(ctx, recordStatusTransition,
+		id,
+		sql.NullString{String: string(from), Valid: from != ""},
+		to,
+		sql.NullString{String: actorFromContext(ctx), Valid: actorFromContext(ctx) != ""},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record status transition: %w", err)
+	}
+	return nil
+}
+
+// GetMilestoneStatusTransitions returns the status history of a milestone, oldest first
+func (dao *PortfolioDao) GetMilestoneStatusTransitions(ctx context.Context, id int) ([]models.Status_Transition, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getStatusTransitions, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := make([]models.Status_Transition, 0)
+	for rows.Next() {
+		var t models.Status_Transition
+		var from, actor sql.NullString
+		if err := rows.Scan(&t.ID, &t.Milestone_id, &from, &t.To, &actor, &t.Transitioned_at); err != nil {
+			return nil, fmt.Errorf("failed to scan status transition row: %w", err)
+		}
+		t.From = models.Milestone_Status(from.String)
+		t.Actor = actor.String
+		transitions = append(transitions, t)
+	}
+
+	return transitions, rows.Err()
+}
+
+// dropPassedPublishAt clears the publish_at time of a published milestone if
+// it has passed already, there is nothing left to schedule. Reports whether
+// it was cleared.
+func dropPassedPublishAt(m *models.Milestone) bool {
+	if m.Status != models.Published || m.Publish_at == nil || m.Publish_at.After(time.Now()) {
+		return false
+	}
+	m.Publish_at = nil
+	return true
+}
+
+// PublishDueMilestones publishes every milestone whose publish_at time has
+// passed, clearing publish_at, and returns how many were published. Each
+// milestone is published in its own transaction so one failure does not
+// hold back the others.
+func (dao *PortfolioDao) PublishDueMilestones(ctx context.Context) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getDueMilestones)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query due milestones: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("failed to scan due milestone id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	published := 0
+	var errs []error
+	for _, id := range ids {
+		err := dao.WithTx(ctx, func(tx *PortfolioDao) error {
+			return tx.trackRevisions(ctx, EntityMilestone, ActionUpdate, []int{id}, func() error {
+				// A no-op for milestones that were published ahead of their publish_at time
+				if err := tx.transitionMilestoneStatus(ctx, id, models.Published); err != nil {
+					return err
+				}
+				if _, err := tx.db.ExecContext(ctx, releaseMilestone, id); err != nil {
+					return fmt.Errorf("failed to release milestone: %w", err)
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("milestone %d: %w", id, err))
+			continue
+		}
+		published++
+	}
+
+	return published, errors.Join(errs...)
+}
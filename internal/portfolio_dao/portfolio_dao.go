@@ -1,7 +1,9 @@
 package portfoliodao
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -11,81 +13,171 @@ import (
 	"github.com/NH-Homelab/portfolio-backend/internal/models"
 )
 
+// SQL condition for a milestone aliased m being publicly visible: the milestone
+// is published and not deleted, its publish_at time (if any) has passed, and
+// its project (if any) is published and not deleted. Checking publish_at here
+// means scheduled milestones never show up early even if the scheduler lags behind.
+const publicMilestoneCondition = `m.status = 'published' AND m.deleted_at IS NULL
+			AND (m.publish_at IS NULL OR m.publish_at <= CURRENT_TIMESTAMP)
+			AND (m.project_id IS NULL OR EXISTS (
+				SELECT 1 FROM projects pp
+				WHERE pp.id = m.project_id AND pp.status = 'published' AND pp.deleted_at IS NULL))`
+
 const (
 	getProjectById = `
 		SELECT 
-			p.id, p.name, p.description, p.created_at,
+			p.id, p.name, p.description, p.status, p.created_at, p.version,
 			m.id, m.title, m.milestone_date, m.description, 
 			m.body_url, m.github_url, m.image_url, 
-			m.milestone_type, m.status, m.project_id
+			m.milestone_type, m.status, m.project_id, m.publish_at, m.version
 		FROM projects p
-		LEFT JOIN milestones m ON p.id = m.project_id
-		WHERE p.id = $1
+		LEFT JOIN milestones m ON p.id = m.project_id AND m.deleted_at IS NULL
+		WHERE p.id = $1 AND p.deleted_at IS NULL
 		ORDER BY m.milestone_date`
 	getAllProjects = `
-		SELECT id, name, description, created_at
+		SELECT id, name, description, status, created_at, version
+		FROM projects
+		WHERE deleted_at IS NULL
+		ORDER BY id`
+	getAllPublishedProjects = `
+		SELECT id, name, description, status, created_at, version
 		FROM projects
+		WHERE status = 'published' AND deleted_at IS NULL
 		ORDER BY id`
+	getPublishedProjectById = `
+		SELECT id, name, description, status, created_at, version
+		FROM projects
+		WHERE id = $1 AND status = 'published' AND deleted_at IS NULL`
+	projectExists = `
+		SELECT 1
+		FROM projects
+		WHERE id = $1 AND deleted_at IS NULL`
 	getMilestoneById = `
 		SELECT id, title, milestone_date, description, body_url, 
-			   github_url, image_url, milestone_type, status, project_id
+			   github_url, image_url, milestone_type, status, project_id, publish_at, version
 		FROM milestones
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
+	getPublishedMilestoneById = `
+		SELECT m.id, m.title, m.milestone_date, m.description, m.body_url,
+			   m.github_url, m.image_url, m.milestone_type, m.status, m.project_id, m.publish_at, m.version
+		FROM milestones m
+		WHERE m.id = $1 AND ` + publicMilestoneCondition
 	getAllPublishedMilestones = `
-		SELECT id, title, milestone_date, description, body_url, 
-			   github_url, image_url, milestone_type, status, project_id
-		FROM milestones
-		WHERE status = 'published'
-		ORDER BY milestone_date DESC`
+		SELECT m.id, m.title, m.milestone_date, m.description, m.body_url,
+			   m.github_url, m.image_url, m.milestone_type, m.status, m.project_id, m.publish_at, m.version
+		FROM milestones m
+		WHERE ` + publicMilestoneCondition + `
+		ORDER BY m.milestone_date DESC`
 	createProject = `
-		INSERT INTO projects (name, description)
-		VALUES ($1, $2)
+		INSERT INTO projects (name, description, status, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
 		RETURNING id`
 	createMilestone = `
 		INSERT INTO milestones (
 			title, milestone_date, description, body_url, 
-			github_url, image_url, milestone_type, status, project_id
+			github_url, image_url, milestone_type, status, project_id, publish_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CURRENT_TIMESTAMP)
 		RETURNING id`
-	deleteProject = `
-		DELETE FROM projects
-		WHERE id = $1`
-	deleteMilestone = `
-		DELETE FROM milestones
+	touchMilestone = `
+		UPDATE milestones SET version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+	// Moving a milestone away changes what readers of its old project see
+	touchProjectUpdatedAt = `
+		UPDATE projects SET updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1`
 )
 
 type PortfolioDao struct {
-	db database.Database
+	db           database.Queryer
+	conn         database.Database // nil when the dao is bound to a transaction
+	queryTimeout time.Duration
+	observers    *observers
+	pending      *[]Change // changes awaiting the commit of the bound transaction
 }
 
 // Update structs for partial updates
 type ProjectUpdate struct {
-	Name        *string `db:"name"`
-	Description *string `db:"description"`
+	Name        *string                `db:"name" json:"name"`
+	Description *string                `db:"description" json:"description"`
+	Status      *models.Project_Status `db:"status" json:"status"`
 }
 
 type MilestoneUpdate struct {
-	Title         *string                `db:"title"`
-	MilestoneDate *time.Time             `db:"milestone_date"`
-	Description   *string                `db:"description"`
-	BodyURL       *string                `db:"body_url"`
-	GithubURL     *string                `db:"github_url"`
-	ImageURL      *string                `db:"image_url"`
-	MilestoneType *models.Milestone_Type `db:"milestone_type"`
-	Status        *string                `db:"status"`
-	ProjectID     *int                   `db:"project_id"`
+	Title         *string                  `db:"title" json:"title"`
+	MilestoneDate *time.Time               `db:"milestone_date" json:"milestone_date"`
+	Description   *string                  `db:"description" json:"description"`
+	BodyURL       *string                  `db:"body_url" json:"body_url"`
+	GithubURL     *string                  `db:"github_url" json:"github_url"`
+	ImageURL      *string                  `db:"image_url" json:"image_url"`
+	MilestoneType *models.Milestone_Type   `db:"milestone_type" json:"milestone_type"`
+	Status        *models.Milestone_Status `db:"status" json:"status"`
+	ProjectID     *int                     `db:"project_id,nullzero" json:"project_id"` // 0 removes the project
+	PublishAt     *time.Time               `db:"publish_at,nullzero" json:"publish_at"` // zero time clears it
+
+	// Replaces the milestone's tags, stored outside the milestones table
+	Tags *[]string `json:"tags"`
 }
 
-// Create new instance of PortfolioDao
-func NewPortfolioDao(db database.Database) *PortfolioDao {
-	return &PortfolioDao{db}
+// Create new instance of PortfolioDao. Every method call is bounded by
+// queryTimeout on top of the caller's context, zero disables the deadline.
+func NewPortfolioDao(db database.Database, queryTimeout time.Duration) *PortfolioDao {
+	return &PortfolioDao{db: db, conn: db, queryTimeout: queryTimeout, observers: &observers{}}
+}
+
+// WithTx runs fn with a PortfolioDao bound to a single transaction. The
+// transaction is committed if fn returns nil and rolled back if it returns an
+// error or panics. Calling WithTx on a dao already bound to a transaction
+// joins that transaction.
+func (dao *PortfolioDao) WithTx(ctx context.Context, fn func(tx *PortfolioDao) error) (err error) {
+	if dao.conn == nil {
+		return fn(dao)
+	}
+
+	tx, err := dao.conn.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []Change
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+			}
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("failed to commit transaction: %w", err)
+			return
+		}
+		dao.observers.notify(pending)
+	}()
+
+	txDao := &PortfolioDao{db: tx, queryTimeout: dao.queryTimeout, observers: dao.observers, pending: &pending}
+	if err = fn(txDao); err != nil {
+		return err
+	}
+	return dao.observers.committing(ctx, txDao, pending)
+}
+
+// withTimeout applies the configured per-query deadline to ctx
+func (dao *PortfolioDao) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if dao.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, dao.queryTimeout)
 }
 
 // buildUpdateQuery dynamically builds an UPDATE query from a struct with pointer fields
-// Only non-nil pointer fields will be included in the update
-func buildUpdateQuery(table string, id int, update interface{}) (string, []interface{}, error) {
+// Only non-nil pointer fields will be included in the update, deleted rows are never updated. A field tagged
+// with the nullzero option stores NULL instead of its zero value. The row's version and updated_at are bumped,
+// and a non-zero version must match the current one.
+func buildUpdateQuery(table string, id, version int, update interface{}) (string, []interface{}, error) {
 	v := reflect.ValueOf(update)
 	t := v.Type()
 
@@ -98,7 +190,7 @@ func buildUpdateQuery(table string, id int, update interface{}) (string, []inter
 		fieldType := t.Field(i)
 
 		// Get the db tag for the column name
-		dbTag := fieldType.Tag.Get("db")
+		dbTag, opts, _ := strings.Cut(fieldType.Tag.Get("db"), ",")
 		if dbTag == "" {
 			continue // Skip fields without db tag
 		}
@@ -106,36 +198,78 @@ func buildUpdateQuery(table string, id int, update interface{}) (string, []inter
 		// Check if the pointer is non-nil
 		if !field.IsNil() {
 			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", dbTag, argCount))
-			args = append(args, field.Elem().Interface())
+			if opts == "nullzero" && field.Elem().IsZero() {
+				args = append(args, nil)
+			} else {
+				args = append(args, field.Elem().Interface())
+			}
 			argCount++
 		}
 	}
 
 	if len(setClauses) == 0 {
-		return "", nil, fmt.Errorf("no fields to update")
+		return "", nil, ErrNoFieldsToUpdate
 	}
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d",
+	setClauses = append(setClauses, "version = version + 1", "updated_at = CURRENT_TIMESTAMP")
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND deleted_at IS NULL",
 		table,
 		strings.Join(setClauses, ", "),
 		argCount)
 	args = append(args, id)
 
+	if version != 0 {
+		query += fmt.Sprintf(" AND version = $%d", argCount+1)
+		args = append(args, version)
+	}
+
 	return query, args, nil
 }
 
-// Returns all projects without their milestones
-func (dao *PortfolioDao) GetAllProjects() ([]models.Project, error) {
-	rows, err := dao.db.Query(getAllProjects)
+// Returns all projects without their milestones, whatever their status
+func (dao *PortfolioDao) GetAllProjects(ctx context.Context) ([]models.Project, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.queryProjects(ctx, getAllProjects)
+}
+
+// Returns all published projects without their milestones
+func (dao *PortfolioDao) GetAllPublishedProjects(ctx context.Context) ([]models.Project, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.queryProjects(ctx, getAllPublishedProjects)
+}
+
+// GetPublishedProjectById returns a project without its milestones if it is publicly visible
+func (dao *PortfolioDao) GetPublishedProjectById(ctx context.Context, id int) (*models.Project, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	projects, err := dao.queryProjects(ctx, getPublishedProjectById, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, notFound("project with id %d", id)
+	}
+
+	return &projects[0], nil
+}
+
+// Helper function to query projects without milestones and handle row scanning
+func (dao *PortfolioDao) queryProjects(ctx context.Context, query string, args ...interface{}) ([]models.Project, error) {
+	rows, err := dao.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query projects: %w", err)
 	}
 	defer rows.Close()
 
-	var projects []models.Project
+	projects := make([]models.Project, 0)
 	for rows.Next() {
 		var p models.Project
-		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Created_at)
+		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Status, &p.Created_at, &p.Version)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project row: %w", err)
 		}
@@ -147,22 +281,25 @@ func (dao *PortfolioDao) GetAllProjects() ([]models.Project, error) {
 }
 
 // Returns a single project with its milestones by ID
-func (dao *PortfolioDao) GetProjectById(id int) (*models.Project, error) {
-	projects, err := dao.queryProjectsWithMilestones(getProjectById, id)
+func (dao *PortfolioDao) GetProjectById(ctx context.Context, id int) (*models.Project, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	projects, err := dao.queryProjectsWithMilestones(ctx, getProjectById, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(projects) == 0 {
-		return nil, fmt.Errorf("project with id %d not found", id)
+		return nil, notFound("project with id %d", id)
 	}
 
 	return &projects[0], nil
 }
 
 // Helper function to query projects with milestones and handle row scanning
-func (dao *PortfolioDao) queryProjectsWithMilestones(query string, args ...interface{}) ([]models.Project, error) {
-	rows, err := dao.db.Query(query, args...)
+func (dao *PortfolioDao) queryProjectsWithMilestones(ctx context.Context, query string, args ...interface{}) ([]models.Project, error) {
+	rows, err := dao.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query projects with milestones: %w", err)
 	}
@@ -180,12 +317,14 @@ func (dao *PortfolioDao) queryProjectsWithMilestones(query string, args ...inter
 		var bodyURL, githubURL, imageURL sql.NullString
 		var milestoneType, milestoneStatus sql.NullString
 		var milestoneProjectID sql.NullInt64
+		var milestonePublishAt sql.NullTime
+		var milestoneVersion sql.NullInt64
 
 		err := rows.Scan(
-			&p.ID, &p.Name, &p.Description, &p.Created_at,
+			&p.ID, &p.Name, &p.Description, &p.Status, &p.Created_at, &p.Version,
 			&milestoneID, &milestoneTitle, &milestoneDate, &milestoneDesc,
 			&bodyURL, &githubURL, &imageURL,
-			&milestoneType, &milestoneStatus, &milestoneProjectID,
+			&milestoneType, &milestoneStatus, &milestoneProjectID, &milestonePublishAt, &milestoneVersion,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -210,214 +349,311 @@ func (dao *PortfolioDao) queryProjectsWithMilestones(query string, args ...inter
 			m.Github_url = githubURL.String
 			m.Image_url = imageURL.String
 			m.Milestone_type = models.Milestone_Type(milestoneType.String)
-			m.Status = milestoneStatus.String
+			m.Status = models.Milestone_Status(milestoneStatus.String)
 			m.Project_id = int(milestoneProjectID.Int64)
+			if milestonePublishAt.Valid {
+				m.Publish_at = &milestonePublishAt.Time
+			}
+			m.Version = int(milestoneVersion.Int64)
 
 			project.Milestones = append(project.Milestones, m)
 		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
 	// Convert map to slice in original order
 	projects := make([]models.Project, 0, len(projectsMap))
+	var milestones []*models.Milestone
 	for _, id := range projectOrder {
 		projects = append(projects, *projectsMap[id])
+		p := &projects[len(projects)-1]
+		for i := range p.Milestones {
+			milestones = append(milestones, &p.Milestones[i])
+		}
 	}
 
-	return projects, rows.Err()
+	if err := dao.loadTags(ctx, milestones); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
 }
 
-// UpdateProject performs a partial update on a project
-func (dao *PortfolioDao) UpdateProject(id int, update ProjectUpdate) error {
-	query, args, err := buildUpdateQuery("projects", id, update)
+// UpdateProject performs a partial update on a project. A non-zero version
+// must match the project's current version.
+func (dao *PortfolioDao) UpdateProject(ctx context.Context, id, version int, update ProjectUpdate) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.updateProject(ctx, id, version, update, ActionUpdate)
+}
+
+// updateProject updates a project, recording the change as a revision with the given action
+func (dao *PortfolioDao) updateProject(ctx context.Context, id, version int, update ProjectUpdate, action string) error {
+	query, args, err := buildUpdateQuery("projects", id, version, update)
 	if err != nil {
 		return err
 	}
 
-	result, err := dao.db.Exec(query, args...)
-	if err != nil {
-		return fmt.Errorf("failed to update project: %w", err)
+	if err := update.validate(); err != nil {
+		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.checkVersion(ctx, EntityProject, id, version); err != nil {
+			return err
+		}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("project with id %d not found", id)
-	}
+		// The project's status decides whether its milestones are public
+		return tx.trackMilestoneVisibility(ctx, id, func() error {
+			return tx.trackRevisions(ctx, EntityProject, action, []int{id}, func() error {
+				return tx.execAffectingOne(ctx, "update project", missedWrite(EntityProject, id, version), query, args...)
+			})
+		})
+	})
+}
 
-	return nil
+// UpdateMilestone performs a partial update on a milestone. The milestone as it
+// would be after the update is validated, so rules spanning several fields
+// hold whichever of them the update changes. A non-zero version must match
+// the milestone's current version.
+func (dao *PortfolioDao) UpdateMilestone(ctx context.Context, id, version int, update MilestoneUpdate) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.updateMilestone(ctx, id, version, update, ActionUpdate)
 }
 
-// UpdateMilestone performs a partial update on a milestone
-func (dao *PortfolioDao) UpdateMilestone(id int, update MilestoneUpdate) error {
-	query, args, err := buildUpdateQuery("milestones", id, update)
-	if err != nil {
-		return err
-	}
+// updateMilestone updates a milestone, recording the change as a revision with the given action
+func (dao *PortfolioDao) updateMilestone(ctx context.Context, id, version int, update MilestoneUpdate, action string) error {
+	// Status changes go through the state machine instead of a plain column update
+	status := update.Status
+	update.Status = nil
 
-	result, err := dao.db.Exec(query, args...)
-	if err != nil {
-		return fmt.Errorf("failed to update milestone: %w", err)
+	if update == (MilestoneUpdate{}) && status == nil {
+		return ErrNoFieldsToUpdate
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		current, err := tx.queryMilestone(ctx, getMilestoneById, id)
+		if err != nil {
+			return err
+		}
+		if err := compareVersion(EntityMilestone, id, version, current.Version); err != nil {
+			return err
+		}
+		previousProject := current.Project_id
+		update.apply(current)
+		if status != nil {
+			current.Status = *status
+		}
+		if err := tx.validateMilestone(ctx, *current); err != nil {
+			return err
+		}
+		if dropPassedPublishAt(current) {
+			update.PublishAt = &time.Time{}
+		}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("milestone with id %d not found", id)
-	}
+		query, args, err := buildUpdateQuery("milestones", id, version, update)
+		if errors.Is(err, ErrNoFieldsToUpdate) {
+			// No columns change, touch the row anyway so a missing milestone is
+			// reported and its version still moves on
+			query, args, err = touchMilestone, []interface{}{id, version}, nil
+		}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.trackRevisions(ctx, EntityMilestone, action, []int{id}, func() error {
+			if err := tx.execAffectingOne(ctx, "update milestone", missedWrite(EntityMilestone, id, version), query, args...); err != nil {
+				return err
+			}
+
+			if status != nil {
+				if err := tx.transitionMilestoneStatus(ctx, id, *status); err != nil {
+					return err
+				}
+			}
+
+			if previousProject != 0 && previousProject != current.Project_id {
+				if _, err := tx.db.ExecContext(ctx, touchProjectUpdatedAt, previousProject); err != nil {
+					return fmt.Errorf("failed to touch project %d: %w", previousProject, err)
+				}
+			}
+
+			if update.Tags != nil {
+				return tx.setMilestoneTags(ctx, id, *update.Tags)
+			}
+
+			return nil
+		})
+	})
 }
 
 // CreateProject creates a new project and returns its ID
-func (dao *PortfolioDao) CreateProject(name, description string) (int, error) {
-	rows, err := dao.db.Query(createProject, name, description)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create project: %w", err)
-	}
-	defer rows.Close()
+func (dao *PortfolioDao) CreateProject(ctx context.Context, name, description string, status models.Project_Status) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
 
-	if !rows.Next() {
-		return 0, fmt.Errorf("failed to get created project id")
+	project := models.Project{Name: name, Description: description, Status: status}
+	if err := validationError(project.Validate()); err != nil {
+		return 0, err
 	}
 
 	var id int
-	if err := rows.Scan(&id); err != nil {
-		return 0, fmt.Errorf("failed to scan project id: %w", err)
+	err := dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		rows, err := tx.db.QueryContext(ctx, createProject, name, description, status)
+		if err != nil {
+			return fmt.Errorf("failed to create project: %w", err)
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			return fmt.Errorf("failed to get created project id")
+		}
+
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan project id: %w", err)
+		}
+		rows.Close()
+
+		return tx.recordCreated(ctx, EntityProject, id)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return id, nil
 }
 
-// CreateMilestone creates a new milestone and returns its ID
-func (dao *PortfolioDao) CreateMilestone(m models.Milestone) (int, error) {
-	rows, err := dao.db.Query(
-		createMilestone,
-		m.Title,
-		m.Milestone_date,
-		m.Description,
-		m.Body_url,
-		m.Github_url,
-		m.Image_url,
-		m.Milestone_type,
-		m.Status,
-		m.Project_id,
-	)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create milestone: %w", err)
-	}
-	defer rows.Close()
+// CreateMilestone creates a new milestone and returns its ID. Milestones without a status start as drafts.
+func (dao *PortfolioDao) CreateMilestone(ctx context.Context, m models.Milestone) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
 
-	if !rows.Next() {
-		return 0, fmt.Errorf("failed to get created milestone id")
+	if m.Status == "" {
+		m.Status = models.Draft
 	}
+	dropPassedPublishAt(&m)
 
 	var id int
-	if err := rows.Scan(&id); err != nil {
-		return 0, fmt.Errorf("failed to scan milestone id: %w", err)
-	}
+	err := dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.validateMilestone(ctx, m); err != nil {
+			return err
+		}
 
-	return id, nil
-}
+		rows, err := tx.db.QueryContext(ctx,
+			createMilestone,
+			m.Title,
+			m.Milestone_date,
+			m.Description,
+			m.Body_url,
+			m.Github_url,
+			m.Image_url,
+			m.Milestone_type,
+			m.Status,
+			nullableID(m.Project_id),
+			m.Publish_at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create milestone: %w", err)
+		}
+		defer rows.Close()
 
-// DeleteProject deletes a project by ID (cascades to milestones)
-func (dao *PortfolioDao) DeleteProject(id int) error {
-	result, err := dao.db.Exec(deleteProject, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete project: %w", err)
-	}
+		if !rows.Next() {
+			return fmt.Errorf("failed to get created milestone id")
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan milestone id: %w", err)
+		}
+		rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("project with id %d not found", id)
-	}
+		if err := tx.recordStatusTransition(ctx, id, "", m.Status); err != nil {
+			return err
+		}
 
-	return nil
-}
+		if len(m.Tags) > 0 {
+			if err := tx.setMilestoneTags(ctx, id, m.Tags); err != nil {
+				return err
+			}
+		}
 
-// DeleteMilestone deletes a milestone by ID
-func (dao *PortfolioDao) DeleteMilestone(id int) error {
-	result, err := dao.db.Exec(deleteMilestone, id)
+		return tx.recordCreated(ctx, EntityMilestone, id)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete milestone: %w", err)
+		return 0, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	return id, nil
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("milestone with id %d not found", id)
-	}
+// GetMilestoneById returns a single milestone by ID
+func (dao *PortfolioDao) GetMilestoneById(ctx context.Context, id int) (*models.Milestone, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
 
-	return nil
+	return dao.queryMilestone(ctx, getMilestoneById, id)
 }
 
-// GetMilestoneById returns a single milestone by ID
-func (dao *PortfolioDao) GetMilestoneById(id int) (*models.Milestone, error) {
-	rows, err := dao.db.Query(getMilestoneById, id)
+// GetPublishedMilestoneById returns a single milestone by ID if it is publicly visible
+func (dao *PortfolioDao) GetPublishedMilestoneById(ctx context.Context, id int) (*models.Milestone, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.queryMilestone(ctx, getPublishedMilestoneById, id)
+}
+
+// Helper function to query a single milestone by ID
+func (dao *PortfolioDao) queryMilestone(ctx context.Context, query string, id int) (*models.Milestone, error) {
+	milestones, err := dao.queryMilestones(ctx, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query milestone: %w", err)
 	}
-	defer rows.Close()
 
-	if !rows.Next() {
-		return nil, fmt.Errorf("milestone with id %d not found", id)
+	if len(milestones) == 0 {
+		return nil, notFound("milestone with id %d", id)
 	}
 
-	var m models.Milestone
-	var bodyURL, githubURL, imageURL sql.NullString
-	var projectID sql.NullInt64
+	return &milestones[0], nil
+}
 
-	err = rows.Scan(
-		&m.ID, &m.Title, &m.Milestone_date, &m.Description,
-		&bodyURL, &githubURL, &imageURL,
-		&m.Milestone_type, &m.Status, &projectID,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan milestone row: %w", err)
-	}
+// GetAllPublishedMilestones returns all milestones with 'published' status
+func (dao *PortfolioDao) GetAllPublishedMilestones(ctx context.Context) ([]models.Milestone, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
 
-	// Convert NullString to regular string
-	m.Body_url = bodyURL.String
-	m.Github_url = githubURL.String
-	m.Image_url = imageURL.String
-	if projectID.Valid {
-		m.Project_id = int(projectID.Int64)
+	milestones, err := dao.queryMilestones(ctx, getAllPublishedMilestones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query published milestones: %w", err)
 	}
-	m.Tags = make([]string, 0)
 
-	return &m, rows.Err()
+	return milestones, nil
 }
 
-// GetAllPublishedMilestones returns all milestones with 'published' status
-func (dao *PortfolioDao) GetAllPublishedMilestones() ([]models.Milestone, error) {
-	rows, err := dao.db.Query(getAllPublishedMilestones)
+// Helper function to query milestones with their tags and handle row scanning
+func (dao *PortfolioDao) queryMilestones(ctx context.Context, query string, args ...interface{}) ([]models.Milestone, error) {
+	rows, err := dao.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query published milestones: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var milestones []models.Milestone
+	milestones := make([]models.Milestone, 0)
 	for rows.Next() {
 		var m models.Milestone
 		var bodyURL, githubURL, imageURL sql.NullString
 		var projectID sql.NullInt64
+		var publishAt sql.NullTime
 
 		err := rows.Scan(
 			&m.ID, &m.Title, &m.Milestone_date, &m.Description,
 			&bodyURL, &githubURL, &imageURL,
-			&m.Milestone_type, &m.Status, &projectID,
+			&m.Milestone_type, &m.Status, &projectID, &publishAt, &m.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan milestone row: %w", err)
@@ -430,10 +666,25 @@ func (dao *PortfolioDao) GetAllPublishedMilestones() ([]models.Milestone, error)
 		if projectID.Valid {
 			m.Project_id = int(projectID.Int64)
 		}
-		m.Tags = make([]string, 0)
+		if publishAt.Valid {
+			m.Publish_at = &publishAt.Time
+		}
 
 		milestones = append(milestones, m)
 	}
 
-	return milestones, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	refs := make([]*models.Milestone, len(milestones))
+	for i := range milestones {
+		refs[i] = &milestones[i]
+	}
+	if err := dao.loadTags(ctx, refs); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
 }
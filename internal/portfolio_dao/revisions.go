@@ -0,0 +1,379 @@
+package portfoliodao
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+// Kinds of rows that have revisions
+const (
+	EntityProject   = "project"
+	EntityMilestone = "milestone"
+)
+
+// What a write did, recorded on its revision and reported with its Change
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionRevert  = "revert"
+)
+
+const (
+	// $2 includes rows in the trash
+	getProjectSnapshot = `
+		SELECT id, name, description, status, created_at, version
+		FROM projects
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)`
+	getMilestoneSnapshot = `
+		SELECT id, title, milestone_date, description, body_url,
+			   github_url, image_url, milestone_type, status, project_id, publish_at, version
+		FROM milestones
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)`
+	insertRevision = `
+		INSERT INTO revisions (entity_type, entity_id, action, before_state, after_state, actor)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	getRevisions = `
+		SELECT id, entity_type, entity_id, action, before_state, after_state, actor, created_at
+		FROM revisions
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY id`
+	getRevision = `
+		SELECT id, entity_type, entity_id, action, before_state, after_state, actor, created_at
+		FROM revisions
+		WHERE entity_type = $1 AND entity_id = $2 AND id = $3`
+)
+
+// Revision snapshot of a project, its milestones have revisions of their own
+type projectSnapshot struct {
+	ID          int                   `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Status      models.Project_Status `json:"status"`
+	Created_at  time.Time             `json:"created_at"`
+	Version     int                   `json:"version"`
+}
+
+// GetRevisions returns the history of a project or milestone, oldest first
+func (dao *PortfolioDao) GetRevisions(ctx context.Context, entity string, id int) ([]models.Revision, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.queryRevisions(ctx, getRevisions, entity, id)
+}
+
+// DiffRevisions compares the state of a project or milestone after two of its revisions
+func (dao *PortfolioDao) DiffRevisions(ctx context.Context, entity string, id, from, to int) ([]models.Field_Change, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	fromRev, err := dao.getRevision(ctx, entity, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := dao.getRevision(ctx, entity, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffStates(fromRev.After, toRev.After)
+}
+
+// RevertProject restores a project's fields to their state after the given revision
+func (dao *PortfolioDao) RevertProject(ctx context.Context, id, revision int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		var p projectSnapshot
+		if err := tx.revisionState(ctx, EntityProject, id, revision, &p); err != nil {
+			return err
+		}
+
+		return tx.updateProject(ctx, id, 0, ProjectUpdate{
+			Name:        &p.Name,
+			Description: &p.Description,
+			Status:      &p.Status,
+		}, ActionRevert)
+	})
+}
+
+// RevertMilestone restores a milestone's fields and tags to their state after
+// the given revision. Status changes still have to follow the state machine.
+func (dao *PortfolioDao) RevertMilestone(ctx context.Context, id, revision int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		var m models.Milestone
+		if err := tx.revisionState(ctx, EntityMilestone, id, revision, &m); err != nil {
+			return err
+		}
+
+		// A zero publish_at clears it
+		publishAt := &time.Time{}
+		if m.Publish_at != nil {
+			publishAt = m.Publish_at
+		}
+
+		return tx.updateMilestone(ctx, id, 0, MilestoneUpdate{
+			Title:         &m.Title,
+			MilestoneDate: &m.Milestone_date,
+			Description:   &m.Description,
+			BodyURL:       &m.Body_url,
+			GithubURL:     &m.Github_url,
+			ImageURL:      &m.Image_url,
+			MilestoneType: &m.Milestone_type,
+			Status:        &m.Status,
+			ProjectID:     &m.Project_id,
+			PublishAt:     publishAt,
+			Tags:          &m.Tags,
+		}, ActionRevert)
+	})
+}
+
+// revisionState decodes the state of an entity after one of its revisions into v
+func (dao *PortfolioDao) revisionState(ctx context.Context, entity string, id, revision int, v interface{}) error {
+	rev, err := dao.getRevision(ctx, entity, id, revision)
+	if err != nil {
+		return err
+	}
+	if rev.After == nil {
+		return conflict("revision %d left no %s to revert to", revision, entity)
+	}
+
+	if err := json.Unmarshal(rev.After, v); err != nil {
+		return fmt.Errorf("failed to decode revision %d: %w", revision, err)
+	}
+	return nil
+}
+
+// trackRevisions records a revision of each of the entities around fn, which
+// is expected to change all of them. Must run inside a transaction.
+func (dao *PortfolioDao) trackRevisions(ctx context.Context, entity, action string, ids []int, fn func() error) error {
+	changes := make([]Change, len(ids))
+	for i, id := range ids {
+		// Purged rows are in the trash right up until they are purged
+		before, err := dao.snapshot(ctx, entity, id, action == ActionPurge)
+		if err != nil {
+			return err
+		}
+		wasPublic, err := dao.isPublic(ctx, entity, id)
+		if err != nil {
+			return err
+		}
+		changes[i] = Change{Entity: entity, ID: id, Action: action, Before: before, WasPublic: wasPublic}
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		var err error
+		if c.After, err = dao.snapshot(ctx, entity, c.ID, false); err != nil {
+			return err
+		}
+		if c.IsPublic, err = dao.isPublic(ctx, entity, c.ID); err != nil {
+			return err
+		}
+		if err := dao.recordRevision(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordCreated records the revision of a newly created entity
+func (dao *PortfolioDao) recordCreated(ctx context.Context, entity string, id int) error {
+	after, err := dao.snapshot(ctx, entity, id, false)
+	if err != nil {
+		return err
+	}
+	isPublic, err := dao.isPublic(ctx, entity, id)
+	if err != nil {
+		return err
+	}
+	return dao.recordRevision(ctx, Change{Entity: entity, ID: id, Action: ActionCreate, After: after, IsPublic: isPublic})
+}
+
+// recordRevision stores the revision a change amounts to and reports the change
+func (dao *PortfolioDao) recordRevision(ctx context.Context, c Change) error {
+	_, err := dao.db.ExecContext(ctx, insertRevision,
+		c.Entity,
+		c.ID,
+		c.Action,
+		nullableJSON(c.Before),
+		nullableJSON(c.After),
+		sql.NullString{String: actorFromContext(ctx), Valid: actorFromContext(ctx) != ""},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	dao.changed(c)
+	return nil
+}
+
+// snapshot returns the current state of an entity as JSON, nil if it does not
+// exist. Rows in the trash only count as existing if includeTrashed is set.
+func (dao *PortfolioDao) snapshot(ctx context.Context, entity string, id int, includeTrashed bool) (json.RawMessage, error) {
+	var state interface{}
+
+	switch entity {
+	case EntityProject:
+		projects, err := dao.queryProjects(ctx, getProjectSnapshot, id, includeTrashed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot project %d: %w", id, err)
+		}
+		if len(projects) == 0 {
+			return nil, nil
+		}
+		p := projects[0]
+		state = projectSnapshot{p.ID, p.Name, p.Description, p.Status, p.Created_at, p.Version}
+	case EntityMilestone:
+		milestones, err := dao.queryMilestones(ctx, getMilestoneSnapshot, id, includeTrashed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot milestone %d: %w", id, err)
+		}
+		if len(milestones) == 0 {
+			return nil, nil
+		}
+		state = milestones[0]
+	default:
+		return nil, fmt.Errorf("unknown revision entity %q", entity)
+	}
+
+	return json.Marshal(state)
+}
+
+func (dao *PortfolioDao) getRevision(ctx context.Context, entity string, id, revision int) (*models.Revision, error) {
+	revisions, err := dao.queryRevisions(ctx, getRevision, entity, id, revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, notFound("revision %d of %s %d", revision, entity, id)
+	}
+	return &revisions[0], nil
+}
+
+func (dao *PortfolioDao) queryRevisions(ctx context.Context, query string, args ...interface{}) ([]models.Revision, error) {
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0)
+	for rows.Next() {
+		var r models.Revision
+		var before, after []byte
+		var actor sql.NullString
+		if err := rows.Scan(&r.ID, &r.Entity_type, &r.Entity_id, &r.Action, &before, &after, &actor, &r.Created_at); err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		if before != nil {
+			r.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			r.After = json.RawMessage(after)
+		}
+		r.Actor = actor.String
+		revisions = append(revisions, r)
+	}
+
+	return revisions, rows.Err()
+}
+
+// queryIDs returns the ids selected by a single column query
+func (dao *PortfolioDao) queryIDs(ctx context.Context, query string, args ...interface{}) ([]int, error) {
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// diffStates lists the top level fields that differ between two JSON objects
+func diffStates(from, to json.RawMessage) ([]models.Field_Change, error) {
+	fromFields, err := decodeFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := decodeFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fromFields)+len(toFields))
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]models.Field_Change, 0)
+	for _, name := range names {
+		if !bytes.Equal(fromFields[name], toFields[name]) {
+			changes = append(changes, models.Field_Change{Field: name, From: fromFields[name], To: toFields[name]})
+		}
+	}
+
+	return changes, nil
+}
+
+// decodeFields splits a JSON object into its compacted fields, null decodes to no fields
+func decodeFields(state json.RawMessage) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if state == nil {
+		return fields, nil
+	}
+
+	if err := json.Unmarshal(state, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode revision state: %w", err)
+	}
+	for name, value := range fields {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, fmt.Errorf("failed to decode revision state: %w", err)
+		}
+		fields[name] = buf.Bytes()
+	}
+
+	return fields, nil
+}
+
+// nullableJSON stores a missing state as NULL. JSON is passed as text, lib/pq
+// would otherwise encode the bytes as bytea.
+func nullableJSON(state json.RawMessage) interface{} {
+	if state == nil {
+		return nil
+	}
+	return string(state)
+}
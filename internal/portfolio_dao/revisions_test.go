@@ -0,0 +1,101 @@
+package portfoliodao
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestDiffStates(t *testing.T) {
+	// Fields are compared as "field:from->to", a missing side as ""
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		changes []string
+	}{
+		{
+			name:    "unchanged",
+			from:    `{"name":"a","version":1}`,
+			to:      `{"version":1,"name":"a"}`,
+			changes: nil,
+		},
+		{
+			name:    "changed fields in name order",
+			from:    `{"version":1,"name":"a","status":"draft"}`,
+			to:      `{"version":2,"name":"b","status":"draft"}`,
+			changes: []string{`name:"a"->"b"`, `version:1->2`},
+		},
+		{
+			name:    "whitespace is not a change",
+			from:    `{"tags": [1, 2], "meta": {"a": 1}}`,
+			to:      `{"tags":[1,2],"meta":{"a":1}}`,
+			changes: nil,
+		},
+		{
+			name:    "compacted values",
+			from:    `{"tags": [1, 2]}`,
+			to:      `{"tags": [1, 2, 3]}`,
+			changes: []string{`tags:[1,2]->[1,2,3]`},
+		},
+		{
+			name:    "added and removed fields",
+			from:    `{"a":1,"b":2}`,
+			to:      `{"b":2,"c":3}`,
+			changes: []string{`a:1->`, `c:->3`},
+		},
+		{
+			name:    "null value differs from a missing field",
+			from:    `{"a":null}`,
+			to:      `{}`,
+			changes: []string{`a:null->`},
+		},
+		{
+			name:    "created",
+			to:      `{"id":1,"name":"a"}`,
+			changes: []string{`id:->1`, `name:->"a"`},
+		},
+		{
+			name:    "deleted",
+			from:    `{"id":1}`,
+			changes: []string{`id:1->`},
+		},
+		{
+			name:    "no state on either side",
+			changes: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := diffStates(rawState(tt.from), rawState(tt.to))
+			if err != nil {
+				t.Fatalf("diffStates() error = %v", err)
+			}
+
+			got := make([]string, 0, len(changes))
+			for _, c := range changes {
+				got = append(got, c.Field+":"+string(c.From)+"->"+string(c.To))
+			}
+			if !slices.Equal(got, tt.changes) {
+				t.Errorf("diffStates() = %v, want %v", got, tt.changes)
+			}
+		})
+	}
+}
+
+func TestDiffStatesInvalid(t *testing.T) {
+	for _, state := range []string{`{"a":`, `[1,2]`, `"text"`} {
+		if _, err := diffStates(rawState(state), nil); err == nil {
+			t.Errorf("diffStates(%s) succeeded, want an error", state)
+		}
+	}
+}
+
+// rawState returns nil for an empty state, as stored for a missing revision side
+func rawState(state string) json.RawMessage {
+	if state == "" {
+		return nil
+	}
+	return json.RawMessage(state)
+}
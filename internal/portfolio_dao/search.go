@@ -0,0 +1,218 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/database"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// ts_headline marks matches with these control characters, the snippet is
+	// HTML escaped before they are swapped for <mark> tags
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+	snippetLength  = 160
+
+	searchPostgres = `
+		SELECT kind, id, title, snippet, rank FROM (
+			SELECT 'project' AS kind, p.id, p.name AS title,
+				ts_headline('english', p.name || ' ' || p.description, q, $2) AS snippet,
+				ts_rank(p.search_vector, q) AS rank
+			FROM projects p, websearch_to_tsquery('english', $1) q
+			WHERE p.search_vector @@ q AND p.status = 'published' AND p.deleted_at IS NULL
+			UNION ALL
+			SELECT 'milestone', m.id, m.title,
+				ts_headline('english', m.title || ' ' || m.description, q, $2),
+				ts_rank(m.search_vector, q)
+			FROM milestones m, websearch_to_tsquery('english', $1) q
+			WHERE m.search_vector @@ q AND ` + publicMilestoneCondition + `
+		) results
+		ORDER BY rank DESC, kind, id
+		LIMIT $3`
+	searchLike = `
+		SELECT 'project', id, name, description
+		FROM projects
+		WHERE status = 'published' AND deleted_at IS NULL AND %s
+		UNION ALL
+		SELECT 'milestone', m.id, m.title, m.description
+		FROM milestones m
+		WHERE ` + publicMilestoneCondition + ` AND %s`
+	likeCondition = `(%[1]s LIKE %[3]s ESCAPE '\' OR %[2]s LIKE %[3]s ESCAPE '\')`
+)
+
+// Search returns projects and published milestones matching the query, best
+// match first, with HTML snippets where matches are wrapped in <mark> tags.
+// Postgres uses its full-text search, other databases fall back to LIKE.
+func (dao *PortfolioDao) Search(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	if dao.db.Dialect() == database.Postgres {
+		return dao.searchFullText(ctx, query, limit)
+	}
+	return dao.searchLike(ctx, query, limit)
+}
+
+func (dao *PortfolioDao) searchFullText(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10", highlightStart, highlightStop)
+
+	rows, err := dao.db.QueryContext(ctx, searchPostgres, query, options, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Kind, &r.ID, &r.Title, &r.Snippet, &r.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan search row: %w", err)
+		}
+		r.Snippet = markHighlights(html.EscapeString(r.Snippet))
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+func (dao *PortfolioDao) searchLike(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return make([]models.SearchResult, 0), nil
+	}
+
+	// Every term has to match, each side of the UNION gets its own placeholders
+	var projectConds, milestoneConds []string
+	var args []interface{}
+	for _, term := range terms {
+		args = append(args, "%"+escapeLike(term)+"%")
+		projectConds = append(projectConds, fmt.Sprintf(likeCondition, "name", "description", fmt.Sprintf("$%d", len(args))))
+	}
+	for _, term := range terms {
+		args = append(args, "%"+escapeLike(term)+"%")
+		milestoneConds = append(milestoneConds, fmt.Sprintf(likeCondition, "title", "description", fmt.Sprintf("$%d", len(args))))
+	}
+
+	sqlQuery := fmt.Sprintf(searchLike, strings.Join(projectConds, " AND "), strings.Join(milestoneConds, " AND "))
+	rows, err := dao.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		var r models.SearchResult
+		var description string
+		if err := rows.Scan(&r.Kind, &r.ID, &r.Title, &description); err != nil {
+			return nil, fmt.Errorf("failed to scan search row: %w", err)
+		}
+		r.Rank = likeRank(r.Title, description, terms)
+		r.Snippet = likeSnippet(r.Title+" "+description, terms)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a search term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// likeRank approximates ts_rank: title matches weigh more than description matches
+func likeRank(title, description string, terms []string) float64 {
+	title, description = strings.ToLower(title), strings.ToLower(description)
+
+	var rank float64
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		rank += float64(strings.Count(title, term)) + 0.4*float64(strings.Count(description, term))
+	}
+	return rank
+}
+
+// likeSnippet cuts a window around the first match and highlights every term in it
+func likeSnippet(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowering changed byte offsets, fall back to case-sensitive matching
+		lower = text
+	}
+
+	start := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, strings.ToLower(term)); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	start = max(start-snippetLength/4, 0)
+	end := min(start+snippetLength, len(text))
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	window, lowerWindow := text[start:end], lower[start:end]
+
+	marked := make([]bool, len(window))
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		for offset := 0; term != ""; {
+			i := strings.Index(lowerWindow[offset:], term)
+			if i < 0 {
+				break
+			}
+			for k := offset + i; k < offset+i+len(term); k++ {
+				marked[k] = true
+			}
+			offset += i + len(term)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(window); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(highlightStart)
+		}
+		b.WriteByte(window[i])
+		if marked[i] && (i == len(window)-1 || !marked[i+1]) {
+			b.WriteString(highlightStop)
+		}
+	}
+
+	return markHighlights(html.EscapeString(b.String()))
+}
+
+// markHighlights swaps the highlight control characters for <mark> tags
+func markHighlights(snippet string) string {
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(snippet)
+}
@@ -0,0 +1,154 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	getTagsForMilestones = `
+		SELECT mt.milestone_id, t.name
+		FROM milestone_tags mt
+		JOIN tags t ON t.id = mt.tag_id
+		WHERE mt.milestone_id IN (%s)
+		ORDER BY t.name`
+	clearMilestoneTags = `
+		DELETE FROM milestone_tags
+		WHERE milestone_id = $1`
+	createTag = `
+		INSERT INTO tags (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO NOTHING`
+	addMilestoneTag = `
+		INSERT INTO milestone_tags (milestone_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = $2
+		ON CONFLICT DO NOTHING`
+	getAllTagCounts = `
+		SELECT t.name, COUNT(m.id)
+		FROM tags t
+		JOIN milestone_tags mt ON mt.tag_id = t.id
+		JOIN milestones m ON m.id = mt.milestone_id
+		WHERE ` + publicMilestoneCondition + `
+		GROUP BY t.name
+		ORDER BY COUNT(m.id) DESC, t.name`
+	getPublishedMilestonesByTag = `
+		SELECT m.id, m.title, m.milestone_date, m.description, m.body_url,
+			   m.github_url, m.image_url, m.milestone_type, m.status, m.project_id, m.publish_at, m.version
+		FROM milestones m
+		JOIN milestone_tags mt ON mt.milestone_id = m.id
+		JOIN tags t ON t.id = mt.tag_id
+		WHERE t.name = $1 AND ` + publicMilestoneCondition + `
+		ORDER BY m.milestone_date DESC`
+)
+
+// GetAllTagCounts returns the tags of published milestones with the number of
+// them using each. Tags only unpublished milestones use are left out, they
+// would reveal those milestones.
+func (dao *PortfolioDao) GetAllTagCounts(ctx context.Context) ([]models.TagCount, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getAllTagCounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var t models.TagCount
+		if err := rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetPublishedMilestonesByTag returns the published milestones carrying the tag, newest first
+func (dao *PortfolioDao) GetPublishedMilestonesByTag(ctx context.Context, tag string) ([]models.Milestone, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	milestones, err := dao.queryMilestones(ctx, getPublishedMilestonesByTag, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query milestones for tag %q: %w", tag, err)
+	}
+
+	return milestones, nil
+}
+
+// loadTags fills in the Tags of every milestone with a single query
+func (dao *PortfolioDao) loadTags(ctx context.Context, milestones []*models.Milestone) error {
+	if len(milestones) == 0 {
+		return nil
+	}
+
+	byID := make(map[int][]*models.Milestone, len(milestones))
+	placeholders := make([]string, 0, len(milestones))
+	args := make([]interface{}, 0, len(milestones))
+	for _, m := range milestones {
+		m.Tags = make([]string, 0)
+		if _, seen := byID[m.ID]; !seen {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+			args = append(args, m.ID)
+		}
+		byID[m.ID] = append(byID[m.ID], m)
+	}
+
+	rows, err := dao.db.QueryContext(ctx, fmt.Sprintf(getTagsForMilestones, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return fmt.Errorf("failed to query milestone tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var milestoneID int
+		var name string
+		if err := rows.Scan(&milestoneID, &name); err != nil {
+			return fmt.Errorf("failed to scan milestone tag row: %w", err)
+		}
+		for _, m := range byID[milestoneID] {
+			m.Tags = append(m.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// setMilestoneTags replaces the tags of a milestone, creating any new tags
+func (dao *PortfolioDao) setMilestoneTags(ctx context.Context, milestoneID int, tags []string) error {
+	if _, err := dao.db.ExecContext(ctx, clearMilestoneTags, milestoneID); err != nil {
+		return fmt.Errorf("failed to clear milestone tags: %w", err)
+	}
+
+	for _, tag := range normalizeTags(tags) {
+		if _, err := dao.db.ExecContext(ctx, createTag, tag); err != nil {
+			return fmt.Errorf("failed to create tag %q: %w", tag, err)
+		}
+		if _, err := dao.db.ExecContext(ctx, addMilestoneTag, milestoneID, tag); err != nil {
+			return fmt.Errorf("failed to add tag %q to milestone: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeTags trims whitespace and drops empty and duplicate tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,51 @@
+package portfoliodao
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+func TestGetAllTagCountsOnlyListsPublicTags(t *testing.T) {
+	dao := newTestDao(t)
+	ctx := context.Background()
+
+	published := createTestProject(t, dao, models.Project_Published)
+	draft := createTestProject(t, dao, models.Project_Draft)
+
+	createTestMilestone(t, dao, published, models.Published, "go", "sql")
+	createTestMilestone(t, dao, published, models.Published, "sql")
+	createTestMilestone(t, dao, published, models.Draft, "secret-launch", "go")
+	createTestMilestone(t, dao, draft, models.Published, "draft-project")
+
+	trashed := createTestMilestone(t, dao, published, models.Published, "trashed")
+	if err := dao.DeleteMilestone(ctx, trashed, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().UTC().Add(time.Hour)
+	if _, err := dao.CreateMilestone(ctx, models.Milestone{
+		Title:          "scheduled",
+		Milestone_date: time.Now().UTC(),
+		Milestone_type: models.Minor,
+		Status:         models.Published,
+		Project_id:     published,
+		Publish_at:     &publishAt,
+		Tags:           []string{"scheduled"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := dao.GetAllTagCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.TagCount{{Name: "sql", Count: 2}, {Name: "go", Count: 1}}
+	if !slices.Equal(tags, want) {
+		t.Errorf("GetAllTagCounts() = %+v, want %+v", tags, want)
+	}
+}
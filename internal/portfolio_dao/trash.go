@@ -0,0 +1,304 @@
+package portfoliodao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	// $3 is the version the row must be at, 0 for any
+	softDeleteProject = `
+		UPDATE projects SET deleted_at = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`
+	softDeleteProjectMilestones = `
+		UPDATE milestones SET deleted_at = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE project_id = $1 AND deleted_at IS NULL`
+	softDeleteMilestone = `
+		UPDATE milestones SET deleted_at = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`
+	getProjectMilestoneIDs = `
+		SELECT id FROM milestones
+		WHERE project_id = $1 AND deleted_at IS NULL`
+	// The milestones deleted along with the project, not those trashed before it
+	getProjectTrashedMilestoneIDs = `
+		SELECT id FROM milestones
+		WHERE project_id = $1 AND deleted_at = (
+			SELECT deleted_at FROM projects WHERE id = $1 AND deleted_at IS NOT NULL)`
+	restoreProjectMilestones = `
+		UPDATE milestones SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE project_id = $1 AND deleted_at = (
+			SELECT deleted_at FROM projects WHERE id = $1 AND deleted_at IS NOT NULL)`
+	restoreProject = `
+		UPDATE projects SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+	getTrashedMilestoneProject = `
+		SELECT m.project_id, p.deleted_at
+		FROM milestones m
+		LEFT JOIN projects p ON p.id = m.project_id
+		WHERE m.id = $1 AND m.deleted_at IS NOT NULL`
+	restoreMilestone = `
+		UPDATE milestones SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+	purgeProject = `
+		DELETE FROM projects
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+	purgeMilestone = `
+		DELETE FROM milestones
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+	getAllProjectMilestoneIDs = `
+		SELECT id FROM milestones
+		WHERE project_id = $1`
+	// Includes the milestones purged along with their project
+	getExpiredMilestoneIDs = `
+		SELECT id FROM milestones
+		WHERE deleted_at IS NOT NULL AND (deleted_at < $1 OR project_id IN (
+			SELECT id FROM projects WHERE deleted_at IS NOT NULL AND deleted_at < $1))`
+	getExpiredProjectIDs = `
+		SELECT id FROM projects
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	purgeMilestonesDeletedBefore = `
+		DELETE FROM milestones
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	purgeProjectsDeletedBefore = `
+		DELETE FROM projects
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	getTrash = `
+		SELECT 'project', id, name, NULL, deleted_at
+		FROM projects
+		WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'milestone', id, title, project_id, deleted_at
+		FROM milestones
+		WHERE deleted_at IS NOT NULL
+		ORDER BY 5 DESC, 1, 2`
+)
+
+// DeleteProject moves a project and its milestones to the trash. A non-zero
+// version must match the project's current version.
+func (dao *PortfolioDao) DeleteProject(ctx context.Context, id, version int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	// The milestones share the project's deleted_at so restoring the project
+	// can tell them apart from milestones that were trashed on their own
+	now := time.Now().UTC()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.checkVersion(ctx, EntityProject, id, version); err != nil {
+			return err
+		}
+
+		milestoneIDs, err := tx.queryIDs(ctx, getProjectMilestoneIDs, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.trackRevisions(ctx, EntityProject, ActionDelete, []int{id}, func() error {
+			return tx.trackRevisions(ctx, EntityMilestone, ActionDelete, milestoneIDs, func() error {
+				if err := tx.execAffectingOne(ctx, "delete project", missedWrite(EntityProject, id, version), softDeleteProject, id, now, version); err != nil {
+					return err
+				}
+
+				if _, err := tx.db.ExecContext(ctx, softDeleteProjectMilestones, id, now); err != nil {
+					return fmt.Errorf("failed to delete project milestones: %w", err)
+				}
+
+				return nil
+			})
+		})
+	})
+}
+
+// DeleteMilestone moves a milestone to the trash. A non-zero version must
+// match the milestone's current version.
+func (dao *PortfolioDao) DeleteMilestone(ctx context.Context, id, version int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.checkVersion(ctx, EntityMilestone, id, version); err != nil {
+			return err
+		}
+
+		return tx.trackRevisions(ctx, EntityMilestone, ActionDelete, []int{id}, func() error {
+			return tx.execAffectingOne(ctx, "delete milestone", missedWrite(EntityMilestone, id, version), softDeleteMilestone, id, time.Now().UTC(), version)
+		})
+	})
+}
+
+// RestoreProject takes a project out of the trash along with the milestones deleted with it
+func (dao *PortfolioDao) RestoreProject(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		milestoneIDs, err := tx.queryIDs(ctx, getProjectTrashedMilestoneIDs, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.trackRevisions(ctx, EntityProject, ActionRestore, []int{id}, func() error {
+			return tx.trackRevisions(ctx, EntityMilestone, ActionRestore, milestoneIDs, func() error {
+				// Milestones first, the project's deleted_at identifies them
+				if _, err := tx.db.ExecContext(ctx, restoreProjectMilestones, id); err != nil {
+					return fmt.Errorf("failed to restore project milestones: %w", err)
+				}
+
+				return tx.execAffectingOne(ctx, "restore project", notFound("trashed project with id %d", id), restoreProject, id)
+			})
+		})
+	})
+}
+
+// RestoreMilestone takes a milestone out of the trash. A milestone whose
+// project is still in the trash cannot be restored on its own.
+func (dao *PortfolioDao) RestoreMilestone(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		rows, err := tx.db.QueryContext(ctx, getTrashedMilestoneProject, id)
+		if err != nil {
+			return fmt.Errorf("failed to query trashed milestone: %w", err)
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			return notFound("trashed milestone with id %d", id)
+		}
+
+		var projectID sql.NullInt64
+		var projectDeletedAt sql.NullTime
+		if err := rows.Scan(&projectID, &projectDeletedAt); err != nil {
+			return fmt.Errorf("failed to scan trashed milestone: %w", err)
+		}
+		rows.Close()
+
+		if projectDeletedAt.Valid {
+			return conflict("project %d of milestone %d is in the trash, restore it first", projectID.Int64, id)
+		}
+
+		return tx.trackRevisions(ctx, EntityMilestone, ActionRestore, []int{id}, func() error {
+			return tx.execAffectingOne(ctx, "restore milestone", notFound("trashed milestone with id %d", id), restoreMilestone, id)
+		})
+	})
+}
+
+// PurgeProject permanently deletes a trashed project and all of its milestones
+func (dao *PortfolioDao) PurgeProject(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		milestoneIDs, err := tx.queryIDs(ctx, getAllProjectMilestoneIDs, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.trackRevisions(ctx, EntityProject, ActionPurge, []int{id}, func() error {
+			return tx.trackRevisions(ctx, EntityMilestone, ActionPurge, milestoneIDs, func() error {
+				return tx.execAffectingOne(ctx, "purge project", notFound("trashed project with id %d", id), purgeProject, id)
+			})
+		})
+	})
+}
+
+// PurgeMilestone permanently deletes a trashed milestone
+func (dao *PortfolioDao) PurgeMilestone(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		return tx.trackRevisions(ctx, EntityMilestone, ActionPurge, []int{id}, func() error {
+			return tx.execAffectingOne(ctx, "purge milestone", notFound("trashed milestone with id %d", id), purgeMilestone, id)
+		})
+	})
+}
+
+// PurgeTrash permanently deletes everything trashed longer than retention ago
+// and returns the number of projects and milestones removed
+func (dao *PortfolioDao) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	cutoff := time.Now().UTC().Add(-retention)
+
+	var purged int
+	err := dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		milestoneIDs, err := tx.queryIDs(ctx, getExpiredMilestoneIDs, cutoff)
+		if err != nil {
+			return err
+		}
+		projectIDs, err := tx.queryIDs(ctx, getExpiredProjectIDs, cutoff)
+		if err != nil {
+			return err
+		}
+		purged = len(milestoneIDs) + len(projectIDs)
+
+		return tx.trackRevisions(ctx, EntityProject, ActionPurge, projectIDs, func() error {
+			return tx.trackRevisions(ctx, EntityMilestone, ActionPurge, milestoneIDs, func() error {
+				// Milestones of expired projects go with them through ON DELETE CASCADE
+				for _, query := range []string{purgeMilestonesDeletedBefore, purgeProjectsDeletedBefore} {
+					if _, err := tx.db.ExecContext(ctx, query, cutoff); err != nil {
+						return fmt.Errorf("failed to purge trash: %w", err)
+					}
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// GetTrash returns every trashed project and milestone, most recently deleted first
+func (dao *PortfolioDao) GetTrash(ctx context.Context) ([]models.Trash_Item, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getTrash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.Trash_Item, 0)
+	for rows.Next() {
+		var item models.Trash_Item
+		var projectID sql.NullInt64
+		if err := rows.Scan(&item.Kind, &item.ID, &item.Title, &projectID, &item.Deleted_at); err != nil {
+			return nil, fmt.Errorf("failed to scan trash row: %w", err)
+		}
+		item.Project_id = int(projectID.Int64)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// execAffectingOne runs a statement that should change exactly one row,
+// returning notFoundErr if it changed none. op describes the statement in errors.
+func (dao *PortfolioDao) execAffectingOne(ctx context.Context, op string, notFoundErr error, query string, args ...interface{}) error {
+	result, err := dao.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
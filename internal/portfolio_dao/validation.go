@@ -0,0 +1,91 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+// validateMilestone checks the milestone's fields and that its project exists.
+// Returns an ErrValidation wrapping models.Validation_Errors when it is invalid.
+func (dao *PortfolioDao) validateMilestone(ctx context.Context, m models.Milestone) error {
+	errs := m.Validate()
+
+	if m.Project_id > 0 {
+		rows, err := dao.db.QueryContext(ctx, projectExists, m.Project_id)
+		if err != nil {
+			return fmt.Errorf("failed to query project: %w", err)
+		}
+		exists := rows.Next()
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to query project: %w", err)
+		}
+
+		if !exists {
+			errs.Add("project_id", fmt.Sprintf("project %d does not exist", m.Project_id))
+		}
+	}
+
+	return validationError(errs)
+}
+
+// validate checks the fields set by a project update
+func (u ProjectUpdate) validate() error {
+	var errs models.Validation_Errors
+
+	if u.Name != nil {
+		errs = append(errs, models.Project{Name: *u.Name, Status: models.Project_Draft}.Validate()...)
+	}
+	if u.Status != nil && !u.Status.Valid() {
+		errs.Add("status", "must be one of draft or published")
+	}
+
+	return validationError(errs)
+}
+
+// apply copies the fields set by the update onto m
+func (u MilestoneUpdate) apply(m *models.Milestone) {
+	if u.Title != nil {
+		m.Title = *u.Title
+	}
+	if u.MilestoneDate != nil {
+		m.Milestone_date = *u.MilestoneDate
+	}
+	if u.Description != nil {
+		m.Description = *u.Description
+	}
+	if u.BodyURL != nil {
+		m.Body_url = *u.BodyURL
+	}
+	if u.GithubURL != nil {
+		m.Github_url = *u.GithubURL
+	}
+	if u.ImageURL != nil {
+		m.Image_url = *u.ImageURL
+	}
+	if u.MilestoneType != nil {
+		m.Milestone_type = *u.MilestoneType
+	}
+	if u.Status != nil {
+		m.Status = *u.Status
+	}
+	if u.ProjectID != nil {
+		m.Project_id = *u.ProjectID
+	}
+	if u.PublishAt != nil {
+		m.Publish_at = u.PublishAt
+		if u.PublishAt.IsZero() {
+			m.Publish_at = nil
+		}
+	}
+}
+
+// nullableID stores an unset (zero) foreign key as NULL
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
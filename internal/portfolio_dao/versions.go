@@ -0,0 +1,62 @@
+package portfoliodao
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	getProjectVersion = `
+		SELECT version
+		FROM projects
+		WHERE id = $1 AND deleted_at IS NULL`
+	getMilestoneVersion = `
+		SELECT version
+		FROM milestones
+		WHERE id = $1 AND deleted_at IS NULL`
+)
+
+// checkVersion returns ErrNotFound if the entity does not exist and
+// ErrPreconditionFailed if it is not at version. A zero version skips the
+// comparison. The write that follows must repeat the comparison, a concurrent
+// transaction may still change the row in between.
+func (dao *PortfolioDao) checkVersion(ctx context.Context, entity string, id, version int) error {
+	query := getProjectVersion
+	if entity == EntityMilestone {
+		query = getMilestoneVersion
+	}
+
+	rows, err := dao.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to query %s version: %w", entity, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return notFound("%s with id %d", entity, id)
+	}
+
+	var current int
+	if err := rows.Scan(&current); err != nil {
+		return fmt.Errorf("failed to scan %s version: %w", entity, err)
+	}
+
+	return compareVersion(entity, id, version, current)
+}
+
+// compareVersion fails with ErrPreconditionFailed unless version is zero or current
+func compareVersion(entity string, id, version, current int) error {
+	if version != 0 && version != current {
+		return preconditionFailed("%s %d is at version %d, not %d", entity, id, current, version)
+	}
+	return nil
+}
+
+// missedWrite is the error for a write that matched no rows although the
+// row was checked earlier in the transaction, i.e. it changed concurrently
+func missedWrite(entity string, id, version int) error {
+	if version == 0 {
+		return notFound("%s with id %d", entity, id)
+	}
+	return preconditionFailed("%s %d changed concurrently", entity, id)
+}
@@ -0,0 +1,311 @@
+package portfoliodao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+)
+
+const (
+	createWebhook = `
+		INSERT INTO webhooks (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+	getAllWebhooks = `
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		ORDER BY id`
+	deleteWebhook = `
+		DELETE FROM webhooks
+		WHERE id = $1`
+	createWebhookDelivery = `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+		VALUES ($1, $2, $3, $4)`
+	getDueWebhookDeliveries = `
+		SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, d.last_error,
+			   d.created_at, d.next_attempt_at, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.next_attempt_at <= $1
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $2`
+	// Unchanged attempts mean no other instance claimed the delivery since it was read
+	claimWebhookDelivery = `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, next_attempt_at = $3
+		WHERE id = $1 AND attempts = $2`
+	deleteWebhookDelivery = `
+		DELETE FROM webhook_deliveries
+		WHERE id = $1`
+	retryWebhookDelivery = `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2, last_error = $3
+		WHERE id = $1`
+	deadLetterWebhookDelivery = `
+		INSERT INTO webhook_dead_letters (webhook_id, event, payload, attempts, last_error, created_at)
+		SELECT webhook_id, event, payload, attempts, $2, created_at
+		FROM webhook_deliveries
+		WHERE id = $1`
+	getWebhookDeadLetters = `
+		SELECT id, webhook_id, event, payload, attempts, last_error, created_at, failed_at
+		FROM webhook_dead_letters
+		ORDER BY failed_at DESC, id DESC`
+	replayWebhookDeadLetter = `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at, created_at)
+		SELECT webhook_id, event, payload, $2, created_at
+		FROM webhook_dead_letters
+		WHERE id = $1`
+	deleteWebhookDeadLetter = `
+		DELETE FROM webhook_dead_letters
+		WHERE id = $1`
+)
+
+// CreateWebhook validates and stores a webhook and returns its ID
+func (dao *PortfolioDao) CreateWebhook(ctx context.Context, w models.Webhook) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	if err := validationError(w.Validate()); err != nil {
+		return 0, err
+	}
+
+	rows, err := dao.db.QueryContext(ctx, createWebhook, w.Url, w.Secret, strings.Join(w.Events, ","))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, fmt.Errorf("failed to get created webhook id")
+	}
+
+	var id int
+	if err := rows.Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to scan webhook id: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetAllWebhooks returns every webhook including its secret
+func (dao *PortfolioDao) GetAllWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.queryWebhooks(ctx)
+}
+
+// DeleteWebhook removes a webhook along with its pending and dead deliveries
+func (dao *PortfolioDao) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.execAffectingOne(ctx, "delete webhook", notFound("webhook with id %d", id), deleteWebhook, id)
+}
+
+// EnqueueWebhookDeliveries queues a delivery of payload to every webhook
+// subscribed to the event type and returns how many were queued. Called
+// within the transaction of the write the event stems from, the deliveries
+// are committed along with it.
+func (dao *PortfolioDao) EnqueueWebhookDeliveries(ctx context.Context, eventType string, payload []byte) (int, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	queued := 0
+	err := dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		webhooks, err := tx.queryWebhooks(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, w := range webhooks {
+			if !w.Subscribed(eventType) {
+				continue
+			}
+			if _, err := tx.db.ExecContext(ctx, createWebhookDelivery, w.ID, eventType, string(payload), now); err != nil {
+				return fmt.Errorf("failed to queue webhook delivery: %w", err)
+			}
+			queued++
+		}
+		return nil
+	})
+
+	return queued, err
+}
+
+// ClaimDueWebhookDeliveries returns up to limit deliveries whose next attempt
+// is due, counting the attempt and holding them back until lease has passed.
+// A delivery claimed by another instance in the meantime is left out.
+func (dao *PortfolioDao) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Webhook_Delivery, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	rows, err := dao.db.QueryContext(ctx, getDueWebhookDeliveries, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []models.Webhook_Delivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	claimed := make([]models.Webhook_Delivery, 0, len(due))
+	for _, d := range due {
+		result, err := dao.db.ExecContext(ctx, claimWebhookDelivery, d.ID, d.Attempts, now.Add(lease))
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook delivery %d: %w", d.ID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 1 {
+			d.Attempts++
+			claimed = append(claimed, d)
+		}
+	}
+
+	return claimed, nil
+}
+
+// CompleteWebhookDelivery removes a delivery that succeeded
+func (dao *PortfolioDao) CompleteWebhookDelivery(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := dao.db.ExecContext(ctx, deleteWebhookDelivery, id); err != nil {
+		return fmt.Errorf("failed to complete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery schedules the next attempt of a delivery that failed
+func (dao *PortfolioDao) RetryWebhookDelivery(ctx context.Context, id int, at time.Time, lastError string) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := dao.db.ExecContext(ctx, retryWebhookDelivery, id, at.UTC(), lastError); err != nil {
+		return fmt.Errorf("failed to reschedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeadLetterWebhookDelivery moves a delivery that failed its last attempt to the dead letters
+func (dao *PortfolioDao) DeadLetterWebhookDelivery(ctx context.Context, id int, lastError string) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.execAffectingOne(ctx, "dead letter webhook delivery", notFound("webhook delivery with id %d", id), deadLetterWebhookDelivery, id, lastError); err != nil {
+			return err
+		}
+		if _, err := tx.db.ExecContext(ctx, deleteWebhookDelivery, id); err != nil {
+			return fmt.Errorf("failed to remove dead webhook delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetWebhookDeadLetters returns the deliveries that failed every attempt, latest first
+func (dao *PortfolioDao) GetWebhookDeadLetters(ctx context.Context) ([]models.Webhook_Delivery, error) {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, getWebhookDeadLetters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.Webhook_Delivery, 0)
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ReplayWebhookDeadLetter queues a dead letter for delivery again, with a
+// fresh set of attempts starting right away
+func (dao *PortfolioDao) ReplayWebhookDeadLetter(ctx context.Context, id int) error {
+	ctx, cancel := dao.withTimeout(ctx)
+	defer cancel()
+
+	return dao.WithTx(ctx, func(tx *PortfolioDao) error {
+		if err := tx.execAffectingOne(ctx, "replay webhook dead letter", notFound("webhook dead letter with id %d", id), replayWebhookDeadLetter, id, time.Now().UTC()); err != nil {
+			return err
+		}
+		if _, err := tx.db.ExecContext(ctx, deleteWebhookDeadLetter, id); err != nil {
+			return fmt.Errorf("failed to remove replayed webhook dead letter: %w", err)
+		}
+		return nil
+	})
+}
+
+func (dao *PortfolioDao) queryWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	rows, err := dao.db.QueryContext(ctx, getAllWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]models.Webhook, 0)
+	for rows.Next() {
+		var w models.Webhook
+		var events string
+		if err := rows.Scan(&w.ID, &w.Url, &w.Secret, &events, &w.Created_at); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook row: %w", err)
+		}
+		w.Events = strings.Split(events, ",")
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// scanWebhookDelivery scans a pending delivery along with its webhook's url
+// and secret, or a dead letter along with the time it failed
+func scanWebhookDelivery(rows *sql.Rows, pending bool) (*models.Webhook_Delivery, error) {
+	var d models.Webhook_Delivery
+	var payload string
+	var lastError sql.NullString
+	var at time.Time
+
+	dest := []interface{}{&d.ID, &d.Webhook_id, &d.Event, &payload, &d.Attempts, &lastError, &d.Created_at, &at}
+	if pending {
+		dest = append(dest, &d.Url, &d.Secret)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+	}
+
+	d.Payload = []byte(payload)
+	d.Last_error = lastError.String
+	if pending {
+		d.Next_attempt_at = &at
+	} else {
+		d.Failed_at = &at
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,95 @@
+package preview
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of content a preview token can grant access to
+const (
+	Project   = "project"
+	Milestone = "milestone"
+)
+
+var (
+	ErrDisabled     = errors.New("previews are disabled, no signing secret is configured")
+	ErrInvalidToken = errors.New("invalid preview token")
+	ErrExpiredToken = errors.New("preview token has expired")
+)
+
+// Mints and verifies expiring HMAC-SHA256 signed preview tokens. A token is
+// base64url("kind:id:expiry") + "." + base64url(signature).
+type Signer struct {
+	secret []byte
+}
+
+// Create new instance of Signer, an empty secret disables previews
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret}
+}
+
+// Mint returns a token granting access to one project or milestone until it expires
+func (s *Signer) Mint(kind string, id int, ttl time.Duration) (string, time.Time, error) {
+	if len(s.secret) == 0 {
+		return "", time.Time{}, ErrDisabled
+	}
+	if kind != Project && kind != Milestone {
+		return "", time.Time{}, fmt.Errorf("unknown preview kind %q", kind)
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	payload := fmt.Sprintf("%s:%d:%d", kind, id, expires.Unix())
+
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+	return token, expires, nil
+}
+
+// Verify checks that the token is validly signed, unexpired and grants access to kind and id
+func (s *Signer) Verify(token, kind string, id int) error {
+	if len(s.secret) == 0 {
+		return ErrDisabled
+	}
+
+	payloadStr, sigStr, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadStr)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigStr)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if !hmac.Equal(sig, s.sign(string(payload))) {
+		return ErrInvalidToken
+	}
+
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 3 || parts[0] != kind || parts[1] != strconv.Itoa(id) {
+		return ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if time.Now().Unix() > expires {
+		return ErrExpiredToken
+	}
+
+	return nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
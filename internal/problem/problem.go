@@ -0,0 +1,77 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+const ContentType = "application/problem+json"
+
+// An RFC 7807 problem details object
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Field level errors of a failed validation
+	Errors models.Validation_Errors `json:"errors,omitempty"`
+}
+
+// Write responds with a problem for status, detail is shown to the client
+func Write(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	write(w, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// WriteError responds with the problem matching a DAO error. Errors of an
+// unknown kind are database failures, their details are not shown to the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	p := Problem{Type: "about:blank", Instance: r.URL.Path, Detail: err.Error()}
+
+	var fieldErrs models.Validation_Errors
+	switch {
+	case errors.As(err, &fieldErrs):
+		p.Status = http.StatusBadRequest
+		p.Detail = portfoliodao.ErrValidation.Error()
+		p.Errors = fieldErrs
+	case errors.Is(err, portfoliodao.ErrValidation):
+		p.Status = http.StatusBadRequest
+	case errors.Is(err, portfoliodao.ErrNotFound):
+		p.Status = http.StatusNotFound
+	case errors.Is(err, portfoliodao.ErrConflict):
+		p.Status = http.StatusConflict
+	case errors.Is(err, portfoliodao.ErrPreconditionFailed):
+		p.Status = http.StatusPreconditionFailed
+	case errors.Is(err, context.DeadlineExceeded):
+		p.Status = http.StatusServiceUnavailable
+		p.Detail = "the database did not respond in time"
+	default:
+		p.Status = http.StatusInternalServerError
+		p.Detail = "an unexpected error occurred"
+	}
+	p.Title = http.StatusText(p.Status)
+
+	write(w, p)
+}
+
+func write(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Failed to encode problem response: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package publichandler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+// writeCacheable encodes v as JSON and serves it with serveCacheable
+func (ph *PublicHandler) writeCacheable(w http.ResponseWriter, r *http.Request, v interface{}, modified time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode %s response: %v", r.URL.Path, err)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	ph.serveCacheable(w, r, "application/json", append(body, '\n'), modified)
+}
+
+// serveCacheable serves body with a strong ETag over it and modified as
+// Last-Modified. Conditional requests matching either are answered with 304.
+// Range requests get the whole body, shared caches must never store a slice
+// of a JSON document. Cache-Control is only set when no earlier step, such as
+// a preview, set one already. modified must be read before the data in the
+// body, so a concurrent change can only make it too early.
+func (ph *PublicHandler) serveCacheable(w http.ResponseWriter, r *http.Request, contentType string, body []byte, modified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if w.Header().Get("Cache-Control") == "" && ph.cacheControl != "" {
+		w.Header().Set("Cache-Control", ph.cacheControl)
+	}
+
+	if notModified(r, etag, modified) {
+		// A 304 has no body to describe
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// notModified reports whether the client's copy is current. If-None-Match
+// takes precedence over If-Modified-Since, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// Weak comparison, a W/ prefix is ignored
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// Last-Modified only has whole seconds
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,92 @@
+package publichandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/events"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+// How often an idle stream gets a comment, keeping proxies from closing it
+const eventsHeartbeat = 30 * time.Second
+
+// streamEvents serves the event log as Server-Sent Events. A client resuming
+// with Last-Event-ID first gets the events it missed, or a reset event when
+// they are no longer retained and it has to reload everything.
+func (ph *PublicHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	var sub *events.Subscription
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		sub = ph.events.Resume(id)
+	} else {
+		sub = ph.events.Subscribe()
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stops nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+
+	if !sub.Resumed {
+		fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", sub.Latest)
+	}
+	for _, e := range sub.Backlog {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Failed to flush event stream: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, open := <-sub.Events:
+			if !open {
+				// Fell behind, the client reconnects and resumes from the log
+				return
+			}
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes e in the text/event-stream format
+func writeEvent(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %d: %w", e.ID, err)
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
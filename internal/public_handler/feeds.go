@@ -0,0 +1,196 @@
+package publichandler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/feeds"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
+)
+
+// Most recent milestones included in a feed
+const feedSize = 50
+
+// Settings of the RSS and Atom feeds
+type Feed_Settings struct {
+	Title    string
+	Base_url string // public url the feeds link to, the feeds are not served when empty
+}
+
+// A format a feed is served in, as the extension of its url
+type feedFormat struct {
+	ext         string
+	contentType string
+	render      func(feeds.Feed) ([]byte, error)
+}
+
+var feedFormats = []feedFormat{
+	{"rss", feeds.RSSContentType, feeds.RSS},
+	{"atom", feeds.AtomContentType, feeds.Atom},
+}
+
+func (ph *PublicHandler) registerFeeds(mux *http.ServeMux) {
+	// Item ids must stay the same for good, they cannot be taken from the
+	// Host of whoever asks
+	if ph.feed.Base_url == "" {
+		log.Printf("Feeds are disabled, FEED_BASE_URL is not set")
+		return
+	}
+
+	for _, format := range feedFormats {
+		// retrieves a feed of the latest published milestones
+		mux.HandleFunc("GET /feed."+format.ext, func(w http.ResponseWriter, r *http.Request) {
+			modified, ok := ph.feedLastModified(w, r)
+			if !ok {
+				return
+			}
+
+			feed := feeds.Feed{
+				Title:       ph.feed.Title,
+				Description: "Latest milestones",
+				Link:        "/api/milestones",
+			}
+			ph.serveFeed(w, r, format, feed, modified, func(models.Milestone) bool { return true })
+		})
+
+		// retrieves a feed of the latest published milestones of a published project
+		mux.HandleFunc("GET /projects/{id}/feed."+format.ext, func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.Atoi(r.PathValue("id"))
+			if err != nil {
+				problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+				return
+			}
+
+			modified, ok := ph.feedLastModified(w, r)
+			if !ok {
+				return
+			}
+
+			project, err := ph.dao.GetProjectById(r.Context(), id)
+			if err != nil {
+				log.Printf("Failed to retrieve project %d: %v", id, err)
+				problem.WriteError(w, r, err)
+				return
+			}
+			if project.Status != models.Project_Published {
+				problem.Write(w, r, http.StatusNotFound, "Project not found")
+				return
+			}
+
+			feed := feeds.Feed{
+				Title:       ph.feed.Title + ": " + project.Name,
+				Description: project.Description,
+				Link:        fmt.Sprintf("/api/projects/%d", id),
+			}
+			ph.serveFeed(w, r, format, feed, modified, func(m models.Milestone) bool {
+				return m.Project_id == id
+			})
+		})
+
+		// retrieves a feed of the latest published milestones of a milestone type
+		mux.HandleFunc("GET /milestone-types/{type}/feed."+format.ext, func(w http.ResponseWriter, r *http.Request) {
+			milestoneType := models.Milestone_Type(r.PathValue("type"))
+			if !milestoneType.Valid() {
+				problem.Write(w, r, http.StatusBadRequest, "Invalid milestone type")
+				return
+			}
+
+			modified, ok := ph.feedLastModified(w, r)
+			if !ok {
+				return
+			}
+
+			feed := feeds.Feed{
+				Title:       fmt.Sprintf("%s: %s milestones", ph.feed.Title, milestoneType),
+				Description: fmt.Sprintf("Latest %s milestones", milestoneType),
+				Link:        "/api/milestones?milestone_type=" + url.QueryEscape(string(milestoneType)),
+			}
+			ph.serveFeed(w, r, format, feed, modified, func(m models.Milestone) bool {
+				return m.Milestone_type == milestoneType
+			})
+		})
+	}
+}
+
+// feedLastModified reads the last modified time ahead of a feed's data,
+// answering the request itself when that fails
+func (ph *PublicHandler) feedLastModified(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	modified, err := ph.dao.GetLastModified(r.Context())
+	if err != nil {
+		log.Printf("Failed to retrieve last modified time: %v", err)
+		problem.WriteError(w, r, err)
+		return time.Time{}, false
+	}
+	return modified, true
+}
+
+// serveFeed fills feed with the latest published milestones accepted by
+// include and serves it in format. feed.Link is a path, made absolute here
+// like every other url of the feed.
+func (ph *PublicHandler) serveFeed(w http.ResponseWriter, r *http.Request, format feedFormat, feed feeds.Feed, modified time.Time, include func(models.Milestone) bool) {
+	milestones, err := ph.dao.GetAllPublishedMilestones(r.Context())
+	if err != nil {
+		log.Printf("Failed to retrieve milestones: %v", err)
+		problem.WriteError(w, r, err)
+		return
+	}
+
+	base := ph.feed.Base_url
+	feed.Author = ph.feed.Title
+	feed.Link = base + feed.Link
+	feed.Self = base + r.URL.Path
+	feed.Updated = modified
+
+	// Milestones come newest first
+	for _, m := range milestones {
+		if len(feed.Items) == feedSize {
+			break
+		}
+		if !include(m) {
+			continue
+		}
+
+		// The milestone's api url identifies it for good, its body is what readers follow
+		id := fmt.Sprintf("%s/api/milestones/%d", base, m.ID)
+		link := m.Body_url
+		if link == "" {
+			link = id
+		}
+
+		feed.Items = append(feed.Items, feeds.Item{
+			ID:        id,
+			Title:     m.Title,
+			Link:      link,
+			Content:   itemContent(m),
+			Category:  string(m.Milestone_type),
+			Published: m.Milestone_date,
+		})
+	}
+
+	body, err := format.render(feed)
+	if err != nil {
+		log.Printf("Failed to render %s feed %s: %v", format.ext, r.URL.Path, err)
+		problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	// Replaces the application/json default set for every response
+	ph.serveCacheable(w, r, format.contentType, body, modified)
+}
+
+// itemContent returns the text of a milestone's feed item, its description
+// followed by a link to its body if it has one
+func itemContent(m models.Milestone) string {
+	if m.Body_url == "" {
+		return m.Description
+	}
+	if m.Description == "" {
+		return m.Body_url
+	}
+	return m.Description + "\n\n" + m.Body_url
+}
@@ -0,0 +1,49 @@
+package publichandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+func TestFeedsIgnoreRequestHost(t *testing.T) {
+	mux, dao := newTestMux(t, Feed_Settings{Title: "Portfolio", Base_url: "https://portfolio.example.com"})
+	_, milestone := createPublishedProject(t, dao, "project")
+
+	bodyURL := "https://blog.example.com/post"
+	if err := dao.UpdateMilestone(context.Background(), milestone, 0, portfoliodao.MilestoneUpdate{BodyURL: &bodyURL}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/feed.rss", "/feed.atom"} {
+		r := httptest.NewRequest(http.MethodGet, "http://evil.example"+path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want 200", path, w.Code)
+		}
+
+		body := w.Body.String()
+		if strings.Contains(body, "evil.example") {
+			t.Errorf("%s links to the request Host: %s", path, body)
+		}
+		if !strings.Contains(body, "https://portfolio.example.com/api/milestones/1") {
+			t.Errorf("%s has no item id under the configured url: %s", path, body)
+		}
+		if !strings.Contains(body, "what happened&#xA;&#xA;"+bodyURL) {
+			t.Errorf("%s item content does not link to the body: %s", path, body)
+		}
+	}
+}
+
+func TestFeedsNeedBaseURL(t *testing.T) {
+	mux, _ := newTestMux(t, Feed_Settings{Title: "Portfolio"})
+
+	if w := get(mux, "/feed.rss", nil); w.Code != http.StatusNotFound {
+		t.Errorf("status = %d without FEED_BASE_URL, want 404", w.Code)
+	}
+}
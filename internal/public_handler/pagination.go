@@ -0,0 +1,128 @@
+package publichandler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+// Response envelope for a page of milestones
+type milestonesPage struct {
+	Milestones  []models.Milestone `json:"milestones"`
+	Next_cursor *string            `json:"next_cursor"`
+}
+
+// encodeCursor turns a cursor into an opaque, url safe token
+func encodeCursor(c portfoliodao.MilestoneCursor) string {
+	raw := fmt.Sprintf("%s|%d", c.Milestone_date.UTC().Format(time.RFC3339Nano), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (*portfoliodao.MilestoneCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	dateStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	date, err := time.Parse(time.RFC3339Nano, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &portfoliodao.MilestoneCursor{Milestone_date: date, ID: id}, nil
+}
+
+// parseDate accepts RFC 3339 timestamps or plain dates. A plain date used as
+// an upper bound covers the whole day.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// parseMilestoneFilter reads limit, cursor, milestone_type, project_id, from, to and order
+func parseMilestoneFilter(query url.Values) (portfoliodao.MilestoneFilter, error) {
+	var filter portfoliodao.MilestoneFilter
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > portfoliodao.MaxPageLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", portfoliodao.MaxPageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	if v := query.Get("milestone_type"); v != "" {
+		t := models.Milestone_Type(v)
+		if !t.Valid() {
+			return filter, fmt.Errorf("unknown milestone_type %q", v)
+		}
+		filter.Type = &t
+	}
+
+	if v := query.Get("project_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid project_id")
+		}
+		filter.ProjectID = &id
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := parseDate(v, false)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date")
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := parseDate(v, true)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date")
+		}
+		filter.To = &to
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
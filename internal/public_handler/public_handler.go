@@ -2,113 +2,268 @@ package publichandler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/NH-Homelab/portfolio-backend/internal/events"
 	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliocache "github.com/NH-Homelab/portfolio-backend/internal/portfolio_cache"
 	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/preview"
+	"github.com/NH-Homelab/portfolio-backend/internal/problem"
 )
 
 type PublicHandler struct {
-	dao *portfoliodao.PortfolioDao
+	dao          *portfoliocache.CachedDao
+	previews     *preview.Signer
+	cacheControl string // sent with cacheable responses, omitted when empty
+	events       *events.Log
+	feed         Feed_Settings
 }
 
-func NewPublicHandler(dao *portfoliodao.PortfolioDao) *PublicHandler {
-	return &PublicHandler{dao}
+func NewPublicHandler(dao *portfoliocache.CachedDao, previews *preview.Signer, cacheControl string, eventLog *events.Log, feed Feed_Settings) *PublicHandler {
+	return &PublicHandler{dao, previews, cacheControl, eventLog, feed}
+}
+
+// checkPreview reports whether the request carries a preview token for kind and id.
+// An invalid or expired token is answered with 403 and ok is false.
+func (ph *PublicHandler) checkPreview(w http.ResponseWriter, r *http.Request, kind string, id int) (previewing bool, ok bool) {
+	token := r.URL.Query().Get("preview")
+	if token == "" {
+		return false, true
+	}
+
+	if err := ph.previews.Verify(token, kind, id); err != nil {
+		log.Printf("Rejected preview of %s %d: %v", kind, id, err)
+		if errors.Is(err, preview.ErrExpiredToken) {
+			problem.Write(w, r, http.StatusForbidden, "Preview link has expired")
+		} else {
+			problem.Write(w, r, http.StatusForbidden, "Invalid preview link")
+		}
+		return false, false
+	}
+
+	// Previews of unpublished content must not be stored by shared caches
+	w.Header().Set("Cache-Control", "private, no-store")
+	return true, true
+}
+
+// publishedOnly returns the milestones with a published status whose
+// publish_at time, if any, has passed. Checked on every request, the
+// milestones may come from the cache.
+func publishedOnly(milestones []models.Milestone) []models.Milestone {
+	now := time.Now()
+	published := make([]models.Milestone, 0, len(milestones))
+	for _, m := range milestones {
+		if m.Status == models.Published && (m.Publish_at == nil || !m.Publish_at.After(now)) {
+			published = append(published, m)
+		}
+	}
+	return published
 }
 
 func (ph *PublicHandler) RegisterHandlers(mux *http.ServeMux) {
-	// retrieves the project by id
+	// retrieves the project by id, a valid ?preview= token also returns unpublished content
 	mux.HandleFunc("GET /api/projects/{id}", func(w http.ResponseWriter, r *http.Request) {
 		idStr := r.PathValue("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			http.Error(w, "Invalid project ID", http.StatusBadRequest)
+			problem.Write(w, r, http.StatusBadRequest, "Invalid project ID")
+			return
+		}
+
+		previewing, ok := ph.checkPreview(w, r, preview.Project, id)
+		if !ok {
+			return
+		}
+
+		modified, err := ph.dao.GetProjectLastModified(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve last modified time of project %d: %v", id, err)
+			problem.WriteError(w, r, err)
 			return
 		}
 
-		project, err := ph.dao.GetProjectById(id)
+		project, err := ph.dao.GetProjectById(r.Context(), id)
 		if err != nil {
 			log.Printf("Failed to retrieve project %d: %v", id, err)
-			http.Error(w, "Project not found", http.StatusNotFound)
+			problem.WriteError(w, r, err)
 			return
 		}
 
-		// Filter to only include published milestones
-		publishedMilestones := make([]models.Milestone, 0)
-		for _, m := range project.Milestones {
-			if m.Status == "published" {
-				publishedMilestones = append(publishedMilestones, m)
+		// Previews show the project as an admin sees it, otherwise unpublished
+		// projects are hidden entirely and only published milestones are included
+		if !previewing {
+			if project.Status != models.Project_Published {
+				problem.Write(w, r, http.StatusNotFound, "Project not found")
+				return
 			}
+			project.Milestones = publishedOnly(project.Milestones)
 		}
-		project.Milestones = publishedMilestones
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(project); err != nil {
-			log.Printf("Failed to encode project response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		}
+		ph.writeCacheable(w, r, project, modified)
 	})
 
 	// retrieves all published projects
 	mux.HandleFunc("GET /api/projects", func(w http.ResponseWriter, r *http.Request) {
-		projects, err := ph.dao.GetAllProjects()
+		modified, err := ph.dao.GetLastModified(r.Context())
 		if err != nil {
-			log.Printf("Failed to retrieve projects: %v", err)
-			http.Error(w, "Failed to retrieve projects", http.StatusInternalServerError)
+			log.Printf("Failed to retrieve last modified time: %v", err)
+			problem.WriteError(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(projects); err != nil {
-			log.Printf("Failed to encode projects response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		projects, err := ph.dao.GetAllPublishedProjects(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve projects: %v", err)
+			problem.WriteError(w, r, err)
+			return
 		}
+
+		ph.writeCacheable(w, r, projects, modified)
 	})
 
-	// retrieves the milestone by id if the milestone is published
+	// retrieves the milestone by id if the milestone is published, or with a valid ?preview= token
 	mux.HandleFunc("GET /api/milestones/{id}", func(w http.ResponseWriter, r *http.Request) {
 		idStr := r.PathValue("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			http.Error(w, "Invalid milestone ID", http.StatusBadRequest)
+			problem.Write(w, r, http.StatusBadRequest, "Invalid milestone ID")
+			return
+		}
+
+		previewing, ok := ph.checkPreview(w, r, preview.Milestone, id)
+		if !ok {
+			return
+		}
+
+		modified, err := ph.dao.GetMilestoneLastModified(r.Context(), id)
+		if err != nil {
+			log.Printf("Failed to retrieve last modified time of milestone %d: %v", id, err)
+			problem.WriteError(w, r, err)
 			return
 		}
 
-		milestone, err := ph.dao.GetMilestoneById(id)
+		// Only returns the milestone if it and its project are published, unless previewing
+		var milestone *models.Milestone
+		if previewing {
+			milestone, err = ph.dao.GetMilestoneById(r.Context(), id)
+		} else {
+			milestone, err = ph.dao.GetPublishedMilestoneById(r.Context(), id)
+		}
 		if err != nil {
 			log.Printf("Failed to retrieve milestone %d: %v", id, err)
-			http.Error(w, "Milestone not found", http.StatusNotFound)
+			problem.WriteError(w, r, err)
 			return
 		}
 
-		// Only return if published
-		if milestone.Status != "published" {
-			http.Error(w, "Milestone not found", http.StatusNotFound)
+		ph.writeCacheable(w, r, milestone, modified)
+	})
+
+	// retrieves a page of published milestones, see parseMilestoneFilter for the query parameters
+	mux.HandleFunc("GET /api/milestones", func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseMilestoneFilter(r.URL.Query())
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		modified, err := ph.dao.GetLastModified(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve last modified time: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		milestones, next, err := ph.dao.GetPublishedMilestonesPage(r.Context(), filter)
+		if err != nil {
+			log.Printf("Failed to retrieve milestones: %v", err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		page := milestonesPage{Milestones: milestones}
+		if next != nil {
+			cursor := encodeCursor(*next)
+			page.Next_cursor = &cursor
+		}
+
+		ph.writeCacheable(w, r, page, modified)
+	})
+
+	// retrieves every tag with its count of published milestones
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		tags, err := ph.dao.GetAllTagCounts(r.Context())
+		if err != nil {
+			log.Printf("Failed to retrieve tags: %v", err)
+			problem.WriteError(w, r, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(milestone); err != nil {
-			log.Printf("Failed to encode milestone response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(tags); err != nil {
+			log.Printf("Failed to encode tags response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		}
 	})
 
-	// retrieves all published milestones
-	mux.HandleFunc("GET /api/milestones", func(w http.ResponseWriter, r *http.Request) {
-		milestones, err := ph.dao.GetAllPublishedMilestones()
+	// retrieves the published milestones carrying a tag
+	mux.HandleFunc("GET /api/tags/{name}/milestones", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+
+		milestones, err := ph.dao.GetPublishedMilestonesByTag(r.Context(), name)
 		if err != nil {
-			log.Printf("Failed to retrieve milestones: %v", err)
-			http.Error(w, "Failed to retrieve milestones", http.StatusInternalServerError)
+			log.Printf("Failed to retrieve milestones for tag %q: %v", name, err)
+			problem.WriteError(w, r, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(milestones); err != nil {
+		if err := json.NewEncoder(w).Encode(publishedOnly(milestones)); err != nil {
 			log.Printf("Failed to encode milestones response: %v", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
+		}
+	})
+
+	// streams changes of public content as Server-Sent Events, see streamEvents
+	mux.HandleFunc("GET /api/events", ph.streamEvents)
+
+	ph.registerFeeds(mux)
+
+	// searches projects and published milestones
+	mux.HandleFunc("GET /api/search", func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			problem.Write(w, r, http.StatusBadRequest, "Missing search query")
+			return
+		}
+
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > portfoliodao.MaxSearchLimit {
+				problem.Write(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", portfoliodao.MaxSearchLimit))
+				return
+			}
+			limit = n
+		}
+
+		results, err := ph.dao.Search(r.Context(), q, limit)
+		if err != nil {
+			log.Printf("Failed to search for %q: %v", q, err)
+			problem.WriteError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			log.Printf("Failed to encode search response: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, "Failed to encode response")
 		}
 	})
 }
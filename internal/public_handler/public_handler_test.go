@@ -0,0 +1,156 @@
+package publichandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/events"
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliocache "github.com/NH-Homelab/portfolio-backend/internal/portfolio_cache"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/preview"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+)
+
+// newTestMux returns the public routes backed by a migrated in-memory database
+func newTestMux(t *testing.T, feed Feed_Settings) (*http.ServeMux, *portfoliocache.CachedDao) {
+	t.Helper()
+
+	db, err := sqlitedb.NewSqliteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	dao := portfoliocache.NewCachedDao(portfoliodao.NewPortfolioDao(db, 5*time.Second), time.Minute, 100)
+	mux := http.NewServeMux()
+	NewPublicHandler(dao, preview.NewSigner([]byte("secret")), "public, max-age=60", events.NewLog(10), feed).RegisterHandlers(mux)
+	return mux, dao
+}
+
+// get performs a GET against mux with the given request headers
+func get(mux *http.ServeMux, path string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+// createPublishedProject creates a published project with a published milestone
+func createPublishedProject(t *testing.T, dao *portfoliocache.CachedDao, name string) (projectID, milestoneID int) {
+	t.Helper()
+	ctx := context.Background()
+
+	projectID, err := dao.CreateProject(ctx, name, "", models.Project_Published)
+	if err != nil {
+		t.Fatal(err)
+	}
+	milestoneID, err = dao.CreateMilestone(ctx, models.Milestone{
+		Title:          name + " milestone",
+		Milestone_date: time.Now().UTC(),
+		Description:    "what happened",
+		Milestone_type: models.Minor,
+		Status:         models.Published,
+		Project_id:     projectID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return projectID, milestoneID
+}
+
+func TestRangeRequestsGetTheWholeBody(t *testing.T) {
+	mux, dao := newTestMux(t, Feed_Settings{})
+	createPublishedProject(t, dao, "project")
+
+	full := get(mux, "/api/projects/1", nil)
+	ranged := get(mux, "/api/projects/1", map[string]string{"Range": "bytes=0-5"})
+
+	if ranged.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", ranged.Code)
+	}
+	if ranged.Body.String() != full.Body.String() {
+		t.Errorf("body = %s, want %s", ranged.Body.String(), full.Body.String())
+	}
+	if got := ranged.Header().Get("Accept-Ranges"); got != "" {
+		t.Errorf("Accept-Ranges = %q, want none", got)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	mux, dao := newTestMux(t, Feed_Settings{})
+	createPublishedProject(t, dao, "project")
+
+	first := get(mux, "/api/projects/1", nil)
+	etag, modified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if etag == "" || modified == "" {
+		t.Fatalf("ETag = %q, Last-Modified = %q, want both", etag, modified)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak etag in a list", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"any etag", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"etag takes precedence", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": modified}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": modified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(mux, "/api/projects/1", tt.header)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 has body %s", w.Body.String())
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), etag)
+			}
+		})
+	}
+}
+
+func TestProjectLastModifiedIgnoresOtherProjects(t *testing.T) {
+	mux, dao := newTestMux(t, Feed_Settings{})
+	ctx := context.Background()
+	createPublishedProject(t, dao, "project")
+	other, _ := createPublishedProject(t, dao, "other")
+
+	project, err := dao.GetProjectLastModified(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Last-Modified has whole seconds, make sure a later write would show
+	time.Sleep(1100 * time.Millisecond)
+	name := "renamed"
+	if err := dao.UpdateProject(ctx, other, 0, portfoliodao.ProjectUpdate{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := get(mux, "/api/projects/1", nil)
+	if want := project.UTC().Format(http.TimeFormat); w.Header().Get("Last-Modified") != want {
+		t.Errorf("Last-Modified = %q after writing another project, want %q", w.Header().Get("Last-Modified"), want)
+	}
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+// Actor recorded on the status transitions and revisions made by the scheduler
+const actor = "scheduler"
+
+// Periodically publishes milestones whose publish_at time has arrived and
+// purges trash older than the retention period
+type Scheduler struct {
+	dao            *portfoliodao.PortfolioDao
+	interval       time.Duration
+	trashRetention time.Duration // zero disables purging
+}
+
+func NewScheduler(dao *portfoliodao.PortfolioDao, interval, trashRetention time.Duration) *Scheduler {
+	return &Scheduler{dao, interval, trashRetention}
+}
+
+// Run does its work immediately and then every interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDue(ctx)
+		s.purgeTrash(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) publishDue(ctx context.Context) {
+	published, err := s.dao.PublishDueMilestones(portfoliodao.WithActor(ctx, actor))
+	if err != nil {
+		log.Printf("Failed to publish scheduled milestones: %v", err)
+	}
+	if published > 0 {
+		log.Printf("Published %d scheduled milestone(s)", published)
+	}
+}
+
+func (s *Scheduler) purgeTrash(ctx context.Context) {
+	if s.trashRetention <= 0 {
+		return
+	}
+
+	purged, err := s.dao.PurgeTrash(portfoliodao.WithActor(ctx, actor), s.trashRetention)
+	if err != nil {
+		log.Printf("Failed to purge trash: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("Purged %d item(s) from the trash", purged)
+	}
+}
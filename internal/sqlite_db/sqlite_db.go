@@ -0,0 +1,124 @@
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/database"
+	_ "modernc.org/sqlite"
+)
+
+type SqliteDB struct {
+	Conn *sql.DB
+}
+
+// Instantiates a SqliteDB connection type. The path may be ":memory:" for a
+// throwaway in-memory database.
+func NewSqliteDB(path string) (*SqliteDB, error) {
+	// Foreign keys are off by default in SQLite and ON DELETE CASCADE relies on them.
+	// Times are written in a format SQLite's own date functions understand.
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Every connection to :memory: is a separate database, and SQLite only
+	// allows a single writer anyway
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &SqliteDB{Conn: db}, nil
+}
+
+func (s *SqliteDB) Close() error {
+	return s.Conn.Close()
+}
+
+func (s *SqliteDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.Conn.ExecContext(ctx, rebind(query), normalizeArgs(args)...)
+}
+
+func (s *SqliteDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.Conn.QueryContext(ctx, rebind(query), normalizeArgs(args)...)
+}
+
+func (s *SqliteDB) Dialect() database.Dialect {
+	return database.Sqlite
+}
+
+func (s *SqliteDB) BeginTx(ctx context.Context) (database.Tx, error) {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &SqliteTx{tx}, nil
+}
+
+type SqliteTx struct {
+	tx *sql.Tx
+}
+
+func (t *SqliteTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, rebind(query), normalizeArgs(args)...)
+}
+
+func (t *SqliteTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, rebind(query), normalizeArgs(args)...)
+}
+
+func (t *SqliteTx) Dialect() database.Dialect {
+	return database.Sqlite
+}
+
+func (t *SqliteTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *SqliteTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// rebind rewrites Postgres $N placeholders into SQLite's ?N form, leaving
+// string literals untouched. RETURNING needs no translation, SQLite supports
+// it natively since 3.35.
+func rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	inLiteral := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inLiteral = !inLiteral
+		case c == '$' && !inLiteral && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			c = '?'
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// normalizeArgs converts times to UTC. SQLite stores them as text, so
+// comparisons and ordering are only correct when every value uses the same offset.
+func normalizeArgs(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			args[i] = t.UTC()
+		case *time.Time:
+			if t != nil {
+				args[i] = t.UTC()
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,245 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NH-Homelab/portfolio-backend/internal/events"
+	"github.com/NH-Homelab/portfolio-backend/internal/models"
+	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+)
+
+// Headers sent with every delivery
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	// sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret>
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// Attempts before a delivery is moved to the dead letters
+	maxAttempts = 8
+	// Delay after the first failed attempt, doubled after every further one
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = time.Hour
+
+	requestTimeout = 10 * time.Second
+	// How long a claimed delivery is held back from other instances
+	claimLease = time.Minute
+	// Deliveries claimed at once
+	batchSize = 20
+)
+
+// Body of a delivery
+type payload struct {
+	Type      string    `json:"type"` // <entity>.<action>, e.g. milestone.published
+	Entity    string    `json:"entity"`
+	Entity_id int       `json:"entity_id"`
+	Action    string    `json:"action"`
+	Time      time.Time `json:"time"`
+
+	// The entity as the public API returns it, null once readers no longer see it
+	Data json.RawMessage `json:"data"`
+}
+
+// Sends the queued deliveries, retrying failures with exponential backoff.
+// Deliveries are queued by Enqueue.
+type Dispatcher struct {
+	dao          *portfoliodao.PortfolioDao
+	client       *http.Client
+	pollInterval time.Duration
+}
+
+func NewDispatcher(dao *portfoliodao.PortfolioDao, pollInterval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		dao:          dao,
+		client:       &http.Client{Timeout: requestTimeout},
+		pollInterval: pollInterval,
+	}
+}
+
+// GenerateSecret returns a random secret for signing deliveries
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header value of a body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue queues a delivery of the event each change amounts to for every
+// webhook subscribed to it. Meant to be registered with
+// PortfolioDao.BeforeCommit, the deliveries are stored in the transaction of
+// the changes so none are lost once they are committed.
+func Enqueue(ctx context.Context, tx *portfoliodao.PortfolioDao, changes []portfoliodao.Change) error {
+	for _, change := range changes {
+		action, ok := events.PublicAction(change)
+		if !ok {
+			continue
+		}
+
+		p := payload{
+			Type:      change.Entity + "." + action,
+			Entity:    change.Entity,
+			Entity_id: change.ID,
+			Action:    action,
+			Time:      time.Now().UTC(),
+		}
+		if change.IsPublic {
+			data, err := publicState(ctx, tx, change.Entity, change.ID)
+			if err != nil {
+				return err
+			}
+			p.Data = data
+		}
+
+		body, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload of %s %d: %w", change.Entity, change.ID, err)
+		}
+		if _, err := tx.EnqueueWebhookDeliveries(ctx, p.Type, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publicState reads an entity the way the public API returns it, within the
+// transaction that changed it. Nil if a later write of the transaction hid it again.
+func publicState(ctx context.Context, tx *portfoliodao.PortfolioDao, entity string, id int) (json.RawMessage, error) {
+	var state interface{}
+	var err error
+	if entity == portfoliodao.EntityProject {
+		state, err = tx.GetPublishedProjectById(ctx, id)
+	} else {
+		state, err = tx.GetPublishedMilestoneById(ctx, id)
+	}
+	if errors.Is(err, portfoliodao.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public state of %s %d: %w", entity, id, err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public state of %s %d: %w", entity, id, err)
+	}
+	return data, nil
+}
+
+// Run sends deliveries as they become due until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue sends the deliveries that are due, batch by batch
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for {
+		deliveries, err := d.dao.ClaimDueWebhookDeliveries(ctx, batchSize, claimLease)
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.deliver(ctx, delivery)
+		}
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver sends a claimed delivery and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.Webhook_Delivery) {
+	sendErr := d.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Shutting down, the lease expires and the attempt is repeated later
+		return
+	}
+
+	var err error
+	switch {
+	case sendErr == nil:
+		err = d.dao.CompleteWebhookDelivery(ctx, delivery.ID)
+	case delivery.Attempts >= maxAttempts:
+		log.Printf("Giving up on webhook delivery %d to %s after %d attempts: %v", delivery.ID, delivery.Url, delivery.Attempts, sendErr)
+		err = d.dao.DeadLetterWebhookDelivery(ctx, delivery.ID, sendErr.Error())
+	default:
+		err = d.dao.RetryWebhookDelivery(ctx, delivery.ID, time.Now().Add(retryDelay(delivery.Attempts)), sendErr.Error())
+	}
+	if err != nil {
+		log.Printf("Failed to record outcome of webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send POSTs the signed payload, anything but a 2xx response is a failure
+func (d *Dispatcher) send(ctx context.Context, delivery models.Webhook_Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("received status %s", resp.Status)
+	}
+	return nil
+}
+
+// retryDelay returns how long to wait after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
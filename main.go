@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 
+	adminhandler "github.com/NH-Homelab/portfolio-backend/internal/admin_handler"
+	"github.com/NH-Homelab/portfolio-backend/internal/auth"
 	"github.com/NH-Homelab/portfolio-backend/internal/config"
+	"github.com/NH-Homelab/portfolio-backend/internal/database"
+	"github.com/NH-Homelab/portfolio-backend/internal/events"
+	"github.com/NH-Homelab/portfolio-backend/internal/migrations"
 	pgdb "github.com/NH-Homelab/portfolio-backend/internal/pg_db"
+	portfoliocache "github.com/NH-Homelab/portfolio-backend/internal/portfolio_cache"
 	portfoliodao "github.com/NH-Homelab/portfolio-backend/internal/portfolio_dao"
+	"github.com/NH-Homelab/portfolio-backend/internal/preview"
 	publichandler "github.com/NH-Homelab/portfolio-backend/internal/public_handler"
+	"github.com/NH-Homelab/portfolio-backend/internal/scheduler"
+	sqlitedb "github.com/NH-Homelab/portfolio-backend/internal/sqlite_db"
+	"github.com/NH-Homelab/portfolio-backend/internal/webhooks"
 )
 
 func logRequest(next http.Handler) http.Handler {
@@ -24,34 +39,179 @@ func setContentType(next http.Handler) http.Handler {
 	})
 }
 
+// pgConfig returns the Postgres connection settings of the config
+func pgConfig(c *config.BackendConfig) pgdb.Pg_Config {
+	return pgdb.Pg_Config{
+		Host:     c.Db_host,
+		Port:     c.Db_port,
+		User:     c.Db_user,
+		Password: c.Db_password,
+		Db_name:  c.Db_name,
+	}
+}
+
+type closableDatabase interface {
+	database.Database
+	Close() error
+}
+
+// openDatabase connects to the database selected by DB_DRIVER
+func openDatabase(c *config.BackendConfig) (closableDatabase, error) {
+	switch database.Dialect(c.Db_driver) {
+	case database.Postgres:
+		return pgdb.NewPostgresDB(pgConfig(c))
+	case database.Sqlite:
+		return sqlitedb.NewSqliteDB(c.Db_path)
+	}
+
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q", c.Db_driver)
+}
+
+// createApiKey handles `apikey create <name>`, used to bootstrap the first
+// credential before any authenticated admin call is possible
+func createApiKey(dao *portfoliodao.PortfolioDao, args []string) error {
+	if len(args) != 2 || args[0] != "create" {
+		return fmt.Errorf("usage: apikey create <name>")
+	}
+
+	key, hash, err := auth.GenerateApiKey()
+	if err != nil {
+		return err
+	}
+
+	id, err := dao.CreateApiKey(context.Background(), args[1], hash)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created api key %d (%s): %s\n", id, args[1], key)
+	return nil
+}
+
+// migrate handles `migrate up`, `migrate down [steps]` and `migrate status`
+func migrate(migrator *migrations.Migrator, args []string) error {
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		fmt.Printf("Applied %d migration(s)\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		count, err := migrator.Down(ctx, steps)
+		fmt.Printf("Reverted %d migration(s)\n", count)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied_at != nil {
+				state = "applied " + s.Applied_at.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
 func main() {
 	backend_config, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load environment variables: %v", err)
 	}
 
-	dbconf := pgdb.Pg_Config{
-		Host:     backend_config.Db_host,
-		Port:     backend_config.Db_port,
-		User:     backend_config.Db_user,
-		Password: backend_config.Db_password,
-		Db_name:  backend_config.Db_name,
+	db, err := openDatabase(backend_config)
+	if err != nil {
+		log.Fatalf("Failed initial database setup: %v", err)
 	}
+	defer db.Close()
 
-	pgdb, err := pgdb.NewPostgresDB(dbconf)
+	migrator, err := migrations.NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Failed initial database setup: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(migrator, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+	log.Printf("Applied %d pending migration(s)", applied)
+
+	dao := portfoliodao.NewPortfolioDao(db, backend_config.Db_query_timeout)
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := createApiKey(dao, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to create api key: %v", err)
+		}
+		return
+	}
+
+	var rsaKey *rsa.PublicKey
+	if backend_config.Jwt_rs256_public_key != "" {
+		rsaKey, err = auth.LoadRSAPublicKey(backend_config.Jwt_rs256_public_key)
+		if err != nil {
+			log.Fatalf("Failed to load JWT public key: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.NewScheduler(dao, backend_config.Publish_interval, backend_config.Trash_retention).Run(ctx)
+
+	authenticator := auth.NewAuthenticator(dao, []byte(backend_config.Jwt_hs256_secret), rsaKey)
+	previews := preview.NewSigner([]byte(backend_config.Preview_secret))
+	if backend_config.Preview_secret == "" {
+		log.Println("WARNING: PREVIEW_SECRET is not set, preview links are disabled")
+	}
+
+	cached := portfoliocache.NewCachedDao(dao, backend_config.Cache_ttl, backend_config.Cache_max_entries)
+	if db.Dialect() == database.Postgres {
+		// Other instances write to the same database, their changes arrive as notifications
+		listener, err := pgdb.NewListener(pgConfig(backend_config), portfoliocache.ChangesChannel)
+		if err != nil {
+			log.Fatalf("Failed to listen for database changes: %v", err)
+		}
+		go listener.Run(ctx, cached.InvalidateNotification, cached.Flush)
 	}
-	defer pgdb.Close()
+	eventLog := events.NewLog(backend_config.Event_log_size)
+	dao.OnChange(eventLog.Record)
+	dao.BeforeCommit(webhooks.Enqueue)
+	go webhooks.NewDispatcher(dao, backend_config.Webhook_poll_interval).Run(ctx)
 
-	dao := portfoliodao.NewPortfolioDao(pgdb)
-	ph := publichandler.NewPublicHandler(dao)
+	feed := publichandler.Feed_Settings{Title: backend_config.Feed_title, Base_url: backend_config.Feed_base_url}
+	ph := publichandler.NewPublicHandler(cached, previews, backend_config.Public_cache_control, eventLog, feed)
+	ah := adminhandler.NewAdminHandler(cached, previews, backend_config.Preview_ttl)
 	mux := http.NewServeMux()
 
 	ph.RegisterHandlers(mux)
+	ah.RegisterHandlers(mux)
 
 	log.Printf("Starting HTTP server on :8080...")
-	err = http.ListenAndServe(":8080", logRequest(setContentType(mux)))
+	err = http.ListenAndServe(":8080", logRequest(setContentType(authenticator.Middleware(mux))))
 	if err != nil {
 		log.Fatalf("HTTP server failed: %v", err)
 	}